@@ -1,25 +1,44 @@
 package ai
 
 import (
-	"os"
+	"context"
 	"fmt"
+
 	"github.com/curator4/io-tui/api"
+	"github.com/curator4/io-tui/config"
 )
 
+// Core resolves the provider for each request from the active AI's api,
+// so switching AIs switches the backend too
 type Core struct {
-	API api.AIAPI
+	providers *Registry
 }
 
-func NewCore() Core {
-	gemini, err := api.NewGeminiAPI()
-	if err != nil {
-		fmt.Printf("❌ %v\n", err)
-		fmt.Println("\nTo fix this:")
-		fmt.Println("1. Set environment variable: export GEMINI_API_KEY=your_key or GOOGLE_API_KEY=your_key")
-		fmt.Println("2. Or add your key to demo_api_key.txt (uncomment the line)")
-		os.Exit(1)
-	}
+func NewCore(providers map[string]config.Provider) Core {
 	return Core{
-		API: gemini,
+		providers: DefaultRegistry(providers),
+	}
+}
+
+// Provider returns the backend registered under apiName
+func (c Core) Provider(apiName string) (api.AIAPI, error) {
+	return c.providers.Get(apiName)
+}
+
+// Models returns the models available for an api, asking the provider
+// when it can discover them and falling back to the static list otherwise.
+// It may block on the network or a key command.
+func (c Core) Models(ctx context.Context, apiName string) ([]string, error) {
+	info, exists := api.AvailableAPIs[apiName]
+	if !exists {
+		return nil, fmt.Errorf("unknown api '%s'", apiName)
+	}
+
+	provider, err := c.Provider(apiName)
+	if err == nil {
+		if lister, ok := provider.(api.ModelLister); ok {
+			return lister.ListModels(ctx)
+		}
 	}
+	return info.Models, nil
 }
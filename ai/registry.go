@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/curator4/io-tui/api"
+	"github.com/curator4/io-tui/config"
+)
+
+// Factory constructs a provider, returning an error when it can't be used
+// (e.g. its API key is missing)
+type Factory func() (api.AIAPI, error)
+
+// UnavailableError reports a provider whose factory failed
+type UnavailableError struct {
+	API string
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s is unavailable: %v", e.API, e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// Registry maps api names (the api column of db.AI) to provider factories.
+// Providers are built on first use and cached, failures are not cached so a
+// provider becomes usable as soon as its configuration is fixed.
+type Registry struct {
+	mu        sync.Mutex
+	factories map[string]Factory
+	providers map[string]api.AIAPI
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]Factory),
+		providers: make(map[string]api.AIAPI),
+	}
+}
+
+// DefaultRegistry registers every provider listed in api.AvailableAPIs.
+// Endpoints and key sources come from the config's [providers.<api>]
+// sections, anything left unset falls back to the usual environment
+// variables. Keys are resolved when a provider is first used, so a key
+// command (e.g. pass) only runs for the providers actually in use. Get
+// blocks while it runs, call it from a tea.Cmd rather than Update.
+func DefaultRegistry(providers map[string]config.Provider) *Registry {
+	r := NewRegistry()
+	r.Register("gemini", func() (api.AIAPI, error) {
+		provider := providers["gemini"]
+		apiKey, err := provider.APIKey()
+		if err != nil {
+			return nil, err
+		}
+		gemini, err := api.NewGeminiAPIFromConfig(provider.BaseURL, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		return gemini, nil
+	})
+	r.Register("openai", func() (api.AIAPI, error) {
+		provider := providers["openai"]
+		apiKey, err := provider.APIKey()
+		if err != nil {
+			return nil, err
+		}
+		openai, err := api.NewOpenAIAPIFromConfig(provider.BaseURL, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		return openai, nil
+	})
+	r.Register("anthropic", func() (api.AIAPI, error) {
+		provider := providers["anthropic"]
+		apiKey, err := provider.APIKey()
+		if err != nil {
+			return nil, err
+		}
+		anthropic, err := api.NewAnthropicAPIFromConfig(provider.BaseURL, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		return anthropic, nil
+	})
+	r.Register("ollama", func() (api.AIAPI, error) {
+		// ollama needs no key
+		if baseURL := providers["ollama"].BaseURL; baseURL != "" {
+			return api.NewOllamaAPI(baseURL), nil
+		}
+		ollama, err := api.NewOllamaAPIFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return ollama, nil
+	})
+	return r
+}
+
+// Register adds or replaces the factory for an api, dropping any cached provider
+func (r *Registry) Register(name string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.factories[name] = factory
+	delete(r.providers, name)
+}
+
+// Get returns the provider for an api, constructing it on first use. The
+// factory runs without the lock held, a key command may take a while.
+func (r *Registry) Get(name string) (api.AIAPI, error) {
+	r.mu.Lock()
+	provider, cached := r.providers[name]
+	factory, ok := r.factories[name]
+	r.mu.Unlock()
+
+	if cached {
+		return provider, nil
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown api '%s'", name)
+	}
+
+	provider, err := factory()
+	if err != nil {
+		return nil, &UnavailableError{API: name, Err: err}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Keep the first provider when two callers built one at once
+	if existing, ok := r.providers[name]; ok {
+		return existing, nil
+	}
+	r.providers[name] = provider
+	return provider, nil
+}
@@ -0,0 +1,429 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/curator4/io-tui/types"
+)
+
+const (
+	defaultAnthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion        = "2023-06-01"
+	anthropicMaxTokens      = 4096
+)
+
+// AnthropicAPI talks to the Anthropic Messages API
+type AnthropicAPI struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// wire format types for /v1/messages
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock is a content block we send: text, tool_use or tool_result
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	Tools         []anthropicTool    `json:"tools,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	TopK          *int               `json:"top_k,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+}
+
+type anthropicContentBlock struct {
+	Type  string                 `json:"type"`
+	Text  string                 `json:"text,omitempty"`
+	ID    string                 `json:"id,omitempty"`
+	Name  string                 `json:"name,omitempty"`
+	Input map[string]interface{} `json:"input,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicContentBlock `json:"content"`
+	Usage   anthropicUsage          `json:"usage"`
+	Error   *anthropicError         `json:"error,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// anthropicEvent covers every event type of the messages event-stream
+type anthropicEvent struct {
+	Type         string                `json:"type"`
+	Index        int                   `json:"index"`
+	ContentBlock anthropicContentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	// message_start carries the input tokens, message_delta the output so far
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage *anthropicUsage `json:"usage,omitempty"`
+	Error *anthropicError `json:"error,omitempty"`
+}
+
+// NewAnthropicAPI creates a client for the Messages API under baseURL
+func NewAnthropicAPI(baseURL, apiKey string) *AnthropicAPI {
+	if baseURL == "" {
+		baseURL = defaultAnthropicBaseURL
+	}
+	return &AnthropicAPI{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}
+}
+
+// NewAnthropicAPIFromEnv configures the client from ANTHROPIC_API_KEY and ANTHROPIC_BASE_URL
+func NewAnthropicAPIFromEnv() (*AnthropicAPI, error) {
+	return NewAnthropicAPIFromConfig("", "")
+}
+
+// NewAnthropicAPIFromConfig uses baseURL and apiKey when set, falling back to
+// the environment otherwise
+func NewAnthropicAPIFromConfig(baseURL, apiKey string) (*AnthropicAPI, error) {
+	if baseURL == "" {
+		baseURL = os.Getenv("ANTHROPIC_BASE_URL")
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("No API key found. Export ANTHROPIC_API_KEY=your_key")
+	}
+	return NewAnthropicAPI(baseURL, apiKey), nil
+}
+
+// resolveModel picks the model for a request, falling back to the default
+func (a *AnthropicAPI) resolveModel(config RequestConfig) string {
+	if config.Model == "" {
+		return AvailableAPIs["anthropic"].DefaultModel
+	}
+	return config.Model
+}
+
+// buildRequest converts our messages into the Messages API shape. The system
+// prompt is a top level field, function results travel as tool_result blocks
+// in a user turn and consecutive turns of the same role are merged.
+func (a *AnthropicAPI) buildRequest(messages []types.Message, config RequestConfig, stream bool) anthropicRequest {
+	// max_tokens is required here, seed is not supported
+	req := anthropicRequest{
+		Model:         a.resolveModel(config),
+		MaxTokens:     anthropicMaxTokens,
+		System:        config.SystemPrompt,
+		Stream:        stream,
+		Temperature:   config.Params.Temperature,
+		TopP:          config.Params.TopP,
+		TopK:          config.Params.TopK,
+		StopSequences: config.Params.StopSequences,
+	}
+	if config.Params.MaxOutputTokens != nil {
+		req.MaxTokens = *config.Params.MaxOutputTokens
+	}
+
+	for _, msg := range messages {
+		var role string
+		var blocks []anthropicBlock
+
+		switch msg.Role {
+		case "user", "assistant":
+			role = msg.Role
+			// the API rejects empty text blocks
+			if strings.TrimSpace(msg.Content) != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+			}
+			for _, call := range msg.FunctionCalls {
+				input, _ := json.Marshal(call.Args)
+				if call.Args == nil {
+					input = []byte("{}")
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+		case "tool":
+			role = "user"
+			blocks = append(blocks, anthropicBlock{Type: "tool_result", ToolUseID: msg.FunctionCallID, Content: msg.Content})
+		default:
+			continue // Skip system messages
+		}
+
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == role {
+			req.Messages[n-1].Content = append(req.Messages[n-1].Content, blocks...)
+			continue
+		}
+		req.Messages = append(req.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, decl := range config.Functions {
+		req.Tools = append(req.Tools, anthropicTool{
+			Name:        decl.Name,
+			Description: decl.Description,
+			InputSchema: decl.Parameters,
+		})
+	}
+
+	return req
+}
+
+// post sends a messages request and checks the status code
+func (a *AnthropicAPI) post(ctx context.Context, body anthropicRequest) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/v1/messages", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, a.statusError(body.Model, resp)
+	}
+	return resp, nil
+}
+
+// statusError decodes an error body, reporting model rejections as ModelError
+func (a *AnthropicAPI) statusError(model string, resp *http.Response) error {
+	raw, _ := io.ReadAll(resp.Body)
+
+	var decoded anthropicResponse
+	message := strings.TrimSpace(string(raw))
+	if json.Unmarshal(raw, &decoded) == nil && decoded.Error != nil {
+		message = decoded.Error.Message
+	}
+
+	err := fmt.Errorf("anthropic: %s: %s", resp.Status, message)
+	if resp.StatusCode == http.StatusNotFound ||
+		(resp.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(message), "model")) {
+		return &ModelError{API: "anthropic", Model: model, Err: err}
+	}
+	return err
+}
+
+func (a *AnthropicAPI) GetResponse(ctx context.Context, messages []types.Message, config RequestConfig) (string, error) {
+	response, err := a.GetResponseWithFunctions(ctx, messages, config)
+	if err != nil {
+		return "", err
+	}
+	return response.Text, nil
+}
+
+func (a *AnthropicAPI) GetResponseWithFunctions(ctx context.Context, messages []types.Message, config RequestConfig) (*ResponseWithFunctions, error) {
+	if len(messages) == 0 {
+		return &ResponseWithFunctions{Text: "No messages to process"}, nil
+	}
+
+	resp, err := a.post(ctx, a.buildRequest(messages, config, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	response := &ResponseWithFunctions{
+		Usage: Usage{PromptTokens: decoded.Usage.InputTokens, CompletionTokens: decoded.Usage.OutputTokens},
+	}
+	for _, block := range decoded.Content {
+		switch block.Type {
+		case "text":
+			response.Text += block.Text
+		case "tool_use":
+			functionCall := FunctionCall{
+				ID:   block.ID,
+				Name: block.Name,
+				Args: make(map[string]interface{}),
+			}
+			for key, value := range block.Input {
+				functionCall.Args[key] = value
+			}
+			response.FunctionCalls = append(response.FunctionCalls, functionCall)
+		}
+	}
+
+	if response.Text == "" && len(response.FunctionCalls) == 0 {
+		response.Text = "No response received"
+	}
+
+	return response, nil
+}
+
+func (a *AnthropicAPI) GetStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan error) {
+	textChan, _, errChan := a.GetEnhancedStreamingResponse(ctx, messages, config)
+	return textChan, errChan
+}
+
+func (a *AnthropicAPI) GetEnhancedStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan StreamResult, <-chan error) {
+	textChan := make(chan string)
+	// buffered so the result is ready by the time textChan closes
+	resultChan := make(chan StreamResult, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(textChan)
+		defer close(resultChan)
+		defer close(errChan)
+
+		if len(messages) == 0 {
+			errChan <- fmt.Errorf("no messages to process")
+			return
+		}
+
+		resp, err := a.post(ctx, a.buildRequest(messages, config, true))
+		if err != nil {
+			errChan <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		// tool_use blocks stream their input as partial JSON keyed by block index
+		type pendingCall struct {
+			id    string
+			name  string
+			input strings.Builder
+		}
+		pending := make(map[int]*pendingCall)
+		var usage Usage
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			// "event:" lines repeat the type that is also inside the data payload
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+
+			var event anthropicEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+				errChan <- fmt.Errorf("failed to decode stream event: %w", err)
+				return
+			}
+
+			switch event.Type {
+			case "message_start":
+				usage.PromptTokens = event.Message.Usage.InputTokens
+				usage.CompletionTokens = event.Message.Usage.OutputTokens
+			case "message_delta":
+				if event.Usage != nil {
+					usage.CompletionTokens = event.Usage.OutputTokens
+				}
+			case "content_block_start":
+				if event.ContentBlock.Type == "tool_use" {
+					pending[event.Index] = &pendingCall{id: event.ContentBlock.ID, name: event.ContentBlock.Name}
+				}
+			case "content_block_delta":
+				switch event.Delta.Type {
+				case "text_delta":
+					if event.Delta.Text != "" {
+						if !sendText(ctx, textChan, event.Delta.Text) {
+							return
+						}
+					}
+				case "input_json_delta":
+					if call, ok := pending[event.Index]; ok {
+						call.input.WriteString(event.Delta.PartialJSON)
+					}
+				}
+			case "error":
+				message := "stream error"
+				if event.Error != nil {
+					message = event.Error.Message
+				}
+				errChan <- fmt.Errorf("anthropic: %s", message)
+				return
+			}
+
+			if event.Type == "message_stop" {
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			errChan <- err
+			return
+		}
+
+		// Send function calls in the order the model issued them
+		indexes := make([]int, 0, len(pending))
+		for index := range pending {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+
+		var functionCalls []FunctionCall
+		for _, index := range indexes {
+			call := pending[index]
+			functionCall := FunctionCall{
+				ID:   call.id,
+				Name: call.name,
+				Args: make(map[string]interface{}),
+			}
+			if input := strings.TrimSpace(call.input.String()); input != "" {
+				json.Unmarshal([]byte(input), &functionCall.Args)
+			}
+			functionCalls = append(functionCalls, functionCall)
+		}
+		resultChan <- StreamResult{FunctionCalls: ensureCallIDs(functionCalls), Usage: usage}
+	}()
+
+	return textChan, resultChan, errChan
+}
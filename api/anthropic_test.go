@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/curator4/io-tui/types"
+)
+
+// anthropicServer answers /v1/messages with body and records the request
+func anthropicServer(t *testing.T, status int, body string) (*httptest.Server, *anthropicRequest) {
+	t.Helper()
+	var received anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("x-api-key = %q, anthropic-version = %q", r.Header.Get("x-api-key"), r.Header.Get("anthropic-version"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestAnthropicStreamText(t *testing.T) {
+	server, received := anthropicServer(t, http.StatusOK, `event: message_start
+data: {"type":"message_start","message":{"usage":{"input_tokens":12,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}
+
+event: message_stop
+data: {"type":"message_stop"}
+`)
+
+	a := NewAnthropicAPI(server.URL, "test-key")
+	text, result, err := drainStream(a.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{Model: "claude-test", SystemPrompt: "be brief"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hello" {
+		t.Errorf("text = %q, want %q", text, "Hello")
+	}
+	if result.Usage != (Usage{PromptTokens: 12, CompletionTokens: 3}) {
+		t.Errorf("usage = %+v, want message_start's input and message_delta's output", result.Usage)
+	}
+	if len(result.FunctionCalls) != 0 {
+		t.Errorf("unexpected function calls %+v", result.FunctionCalls)
+	}
+
+	if !received.Stream || received.Model != "claude-test" || received.System != "be brief" {
+		t.Errorf("request stream=%v model=%q system=%q", received.Stream, received.Model, received.System)
+	}
+	if received.MaxTokens != anthropicMaxTokens {
+		t.Errorf("request max_tokens = %d", received.MaxTokens)
+	}
+}
+
+func TestAnthropicStreamToolCalls(t *testing.T) {
+	// Two tool_use blocks, their input split over deltas and interleaved
+	server, _ := anthropicServer(t, http.StatusOK, `data: {"type":"message_start","message":{"usage":{"input_tokens":5,"output_tokens":0}}}
+
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_a","name":"remember","input":{}}}
+
+data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_b","name":"manifest","input":{}}}
+
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"name\":"}}
+
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"content\":"}}
+
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"likes tea\"}"}}
+
+data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"Pikachu\"}"}}
+
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}
+
+data: {"type":"message_stop"}
+`)
+
+	a := NewAnthropicAPI(server.URL, "test-key")
+	text, result, err := drainStream(a.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "" {
+		t.Errorf("text = %q, want none", text)
+	}
+	if len(result.FunctionCalls) != 2 {
+		t.Fatalf("function calls = %+v, want two", result.FunctionCalls)
+	}
+	first, second := result.FunctionCalls[0], result.FunctionCalls[1]
+	if first.ID != "toolu_a" || first.Name != "remember" || first.Args["content"] != "likes tea" {
+		t.Errorf("first call = %+v", first)
+	}
+	if second.ID != "toolu_b" || second.Name != "manifest" || second.Args["name"] != "Pikachu" {
+		t.Errorf("second call = %+v", second)
+	}
+	if result.Usage != (Usage{PromptTokens: 5, CompletionTokens: 7}) {
+		t.Errorf("usage = %+v", result.Usage)
+	}
+}
+
+func TestAnthropicStreamError(t *testing.T) {
+	server, _ := anthropicServer(t, http.StatusOK, `data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"partial"}}
+
+event: error
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+`)
+
+	a := NewAnthropicAPI(server.URL, "test-key")
+	text, _, err := drainStream(a.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{}))
+	if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Fatalf("err = %v, want the stream's error", err)
+	}
+	if text != "partial" {
+		t.Errorf("text = %q, want the text before the error", text)
+	}
+}
+
+func TestAnthropicUnknownModel(t *testing.T) {
+	server, _ := anthropicServer(t, http.StatusNotFound, `{"type":"error","error":{"type":"not_found_error","message":"model: claude-nope"}}`)
+
+	a := NewAnthropicAPI(server.URL, "test-key")
+	_, err := a.GetResponseWithFunctions(context.Background(), helloMessages, RequestConfig{Model: "claude-nope"})
+	var modelErr *ModelError
+	if !errors.As(err, &modelErr) || modelErr.Model != "claude-nope" || modelErr.API != "anthropic" {
+		t.Fatalf("err = %v, want a ModelError for claude-nope", err)
+	}
+
+	// Streams report the rejection the same way
+	_, _, err = drainStream(a.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{Model: "claude-nope"}))
+	if !errors.As(err, &modelErr) {
+		t.Fatalf("stream err = %v, want a ModelError", err)
+	}
+}
+
+func TestAnthropicMergesTurns(t *testing.T) {
+	server, received := anthropicServer(t, http.StatusOK, `{"content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":20,"output_tokens":1}}`)
+
+	messages := []types.Message{
+		{Role: "user", Content: "remember two things"},
+		{Role: "user", Content: "please"},
+		{Role: "assistant", FunctionCalls: []types.FunctionCall{
+			{ID: "toolu_a", Name: "remember", Args: map[string]interface{}{"content": "likes tea"}},
+			{ID: "toolu_b", Name: "remember"},
+		}},
+		{Role: "tool", Content: "remembered", FunctionCallID: "toolu_a", FunctionName: "remember"},
+		{Role: "tool", Content: "remembered", FunctionCallID: "toolu_b", FunctionName: "remember"},
+		{Role: "system", Content: "not sent"},
+		{Role: "user", Content: "thanks"},
+	}
+	a := NewAnthropicAPI(server.URL, "test-key")
+	response, err := a.GetResponseWithFunctions(context.Background(), messages, RequestConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Text != "Done" || response.Usage != (Usage{PromptTokens: 20, CompletionTokens: 1}) {
+		t.Errorf("response = %+v", response)
+	}
+
+	// user, assistant tool_use, then the results and the next message in one user turn
+	if len(received.Messages) != 3 {
+		t.Fatalf("request messages = %+v, want three turns", received.Messages)
+	}
+	first, calls, results := received.Messages[0], received.Messages[1], received.Messages[2]
+	if first.Role != "user" || len(first.Content) != 2 {
+		t.Errorf("first turn = %+v, want both user messages", first)
+	}
+	if calls.Role != "assistant" || len(calls.Content) != 2 || calls.Content[0].Type != "tool_use" || calls.Content[0].ID != "toolu_a" {
+		t.Fatalf("assistant turn = %+v", calls)
+	}
+	if string(calls.Content[1].Input) != "{}" {
+		t.Errorf("input of a call without args = %s, want {}", calls.Content[1].Input)
+	}
+	if results.Role != "user" || len(results.Content) != 3 ||
+		results.Content[0].Type != "tool_result" || results.Content[0].ToolUseID != "toolu_a" ||
+		results.Content[1].ToolUseID != "toolu_b" || results.Content[2].Text != "thanks" {
+		t.Errorf("results turn = %+v", results)
+	}
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// FunctionDeclaration is a provider independent description of a function
+// the model may call. Parameters holds a JSON schema object.
+type FunctionDeclaration struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// ensureCallIDs gives every call an id so its result can be matched up,
+// not every provider sends one
+func ensureCallIDs(calls []FunctionCall) []FunctionCall {
+	for i := range calls {
+		if calls[i].ID == "" {
+			buf := make([]byte, 8)
+			rand.Read(buf)
+			calls[i].ID = "call_" + hex.EncodeToString(buf)
+		}
+	}
+	return calls
+}
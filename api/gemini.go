@@ -3,6 +3,8 @@ package api
 import (
 	"fmt"
     "context"
+    "errors"
+    "net/http"
     "os"
     "strings"
     "google.golang.org/genai"
@@ -14,37 +16,76 @@ type GeminiAPI struct {
 }
 
 
-// defineManifestFunction creates the function schema for manifesting characters
-func defineManifestFunction() *genai.FunctionDeclaration {
-    return &genai.FunctionDeclaration{
-        Name: "manifest_character",
-        Description: "Call this function ONLY when the user mentions 'manifest' with a character name AND provides an image URL. If no image URL is provided, do NOT call this function - instead ask the user to provide an image URL.",
-        Parameters: &genai.Schema{
-            Type: genai.TypeObject,
-            Properties: map[string]*genai.Schema{
-                "name": {
-                    Type: genai.TypeString,
-                    Description: "Just the character name (e.g., 'L', 'Sherlock Holmes', 'Tony Stark')",
-                },
-                "image_url": {
-                    Type: genai.TypeString,
-                    Description: "The EXACT image URL provided by the user in their message. Must be a direct link to a PNG or JPG file. NEVER use placeholders or make up URLs.",
-                },
-                "description": {
-                    Type: genai.TypeString,
-                    Description: "You must provide a detailed description of the character's personality, traits, background, and how they should behave. Include specific details about their speaking style, mannerisms, and key characteristics. This will be used to automatically generate their system prompt.",
-                },
-            },
-            Required: []string{"name", "image_url", "description"},
-        },
+// geminiTools converts our function declarations, genai accepts the
+// parameters as plain JSON schema
+func geminiTools(functions []FunctionDeclaration) []*genai.Tool {
+    if len(functions) == 0 {
+        return nil
     }
+    var declarations []*genai.FunctionDeclaration
+    for _, decl := range functions {
+        declarations = append(declarations, &genai.FunctionDeclaration{
+            Name:                 decl.Name,
+            Description:          decl.Description,
+            ParametersJsonSchema: decl.Parameters,
+        })
+    }
+    return []*genai.Tool{{FunctionDeclarations: declarations}}
+}
+
+// geminiUsage reads the token counts, thinking tokens are billed as output
+func geminiUsage(metadata *genai.GenerateContentResponseUsageMetadata) Usage {
+    if metadata == nil {
+        return Usage{}
+    }
+    return Usage{
+        PromptTokens:     int(metadata.PromptTokenCount),
+        CompletionTokens: int(metadata.CandidatesTokenCount + metadata.ThoughtsTokenCount),
+    }
+}
+
+// geminiConfig builds the generation config from the request config
+func geminiConfig(config RequestConfig) *genai.GenerateContentConfig {
+    genConfig := &genai.GenerateContentConfig{
+        Tools:         geminiTools(config.Functions),
+        StopSequences: config.Params.StopSequences,
+    }
+    if config.SystemPrompt != "" {
+        genConfig.SystemInstruction = genai.NewContentFromText(config.SystemPrompt, genai.RoleUser)
+    }
+
+    params := config.Params
+    if params.Temperature != nil {
+        genConfig.Temperature = genai.Ptr(float32(*params.Temperature))
+    }
+    if params.TopP != nil {
+        genConfig.TopP = genai.Ptr(float32(*params.TopP))
+    }
+    if params.TopK != nil {
+        genConfig.TopK = genai.Ptr(float32(*params.TopK))
+    }
+    if params.MaxOutputTokens != nil {
+        genConfig.MaxOutputTokens = int32(*params.MaxOutputTokens)
+    }
+    if params.Seed != nil {
+        genConfig.Seed = genai.Ptr(int32(*params.Seed))
+    }
+    return genConfig
 }
 
 func NewGeminiAPI() (*GeminiAPI, error) {
+    return NewGeminiAPIFromConfig("", "")
+}
+
+// NewGeminiAPIFromConfig uses baseURL and apiKey when set, falling back to
+// Google's endpoint and the environment / demo_api_key.txt otherwise
+func NewGeminiAPIFromConfig(baseURL, apiKey string) (*GeminiAPI, error) {
     ctx := context.Background()
     
     // Get API key from environment (check both GEMINI_API_KEY and GOOGLE_API_KEY), or read from demo file
-    apiKey := os.Getenv("GEMINI_API_KEY")
+    if apiKey == "" {
+        apiKey = os.Getenv("GEMINI_API_KEY")
+    }
     if apiKey == "" {
         apiKey = os.Getenv("GOOGLE_API_KEY")
     }
@@ -73,7 +114,8 @@ func NewGeminiAPI() (*GeminiAPI, error) {
     
     // Create client with API key
     client, err := genai.NewClient(ctx, &genai.ClientConfig{
-        APIKey: apiKey,
+        APIKey:      apiKey,
+        HTTPOptions: genai.HTTPOptions{BaseURL: baseURL},
     })
     
     if err != nil {
@@ -85,125 +127,126 @@ func NewGeminiAPI() (*GeminiAPI, error) {
     }, nil
 }
 
-func (g *GeminiAPI) prepareChatSession(messages []types.Message, systemPrompt string) (*genai.Chat, string, error) {
-    ctx := context.Background()
-    
-    if len(messages) == 0 {
-        return nil, "", fmt.Errorf("no messages to process")
+// resolveModel picks the model for a request, making sure it was meant for gemini
+func (g *GeminiAPI) resolveModel(config RequestConfig) (string, error) {
+    if config.API != "" && config.API != "gemini" {
+        return "", fmt.Errorf("request for api '%s' was sent to gemini", config.API)
     }
-    
-    // Convert ALL messages to genai Content format for history
-    var history []*genai.Content
-    var lastUserMessage string
-    
-    // Add all messages except the very last one to history
-    for i, msg := range messages {
-        var role genai.Role
-        if msg.Role == "user" {
-            role = genai.RoleUser
-        } else if msg.Role == "assistant" {
-            role = genai.RoleModel
-        }
-        
-        // Add all messages except the last one to history
-        if i < len(messages)-1 {
-            history = append(history, genai.NewContentFromText(msg.Content, role))
-        } else {
-            // The last message should be a user message that we'll send
-            if msg.Role == "user" {
-                lastUserMessage = msg.Content
+    if config.Model == "" {
+        return AvailableAPIs["gemini"].DefaultModel, nil
+    }
+    return config.Model, nil
+}
+
+// wrapModelError turns a backend rejection of the model into a ModelError
+func wrapModelError(model string, err error) error {
+    var apiErr genai.APIError
+    if !errors.As(err, &apiErr) {
+        return err
+    }
+    if apiErr.Code == http.StatusNotFound ||
+        (apiErr.Code == http.StatusBadRequest && strings.Contains(strings.ToLower(apiErr.Message), "model")) {
+        return &ModelError{API: "gemini", Model: model, Err: err}
+    }
+    return err
+}
+
+// toGeminiContents converts our messages to genai contents. Function calls
+// become model parts and their results user function response parts, with
+// consecutive results merged into one turn like gemini expects.
+func toGeminiContents(messages []types.Message) []*genai.Content {
+    var contents []*genai.Content
+    for _, msg := range messages {
+        switch msg.Role {
+        case "user":
+            contents = append(contents, genai.NewContentFromText(msg.Content, genai.RoleUser))
+        case "assistant":
+            content := &genai.Content{Role: genai.RoleModel}
+            if msg.Content != "" {
+                content.Parts = append(content.Parts, genai.NewPartFromText(msg.Content))
+            }
+            for _, call := range msg.FunctionCalls {
+                content.Parts = append(content.Parts, genai.NewPartFromFunctionCall(call.Name, call.Args))
+            }
+            if len(content.Parts) > 0 {
+                contents = append(contents, content)
             }
+        case "tool":
+            part := genai.NewPartFromFunctionResponse(msg.FunctionName, map[string]any{"result": msg.Content})
+            if n := len(contents); n > 0 && contents[n-1].Role == genai.RoleUser && contents[n-1].Parts[0].FunctionResponse != nil {
+                contents[n-1].Parts = append(contents[n-1].Parts, part)
+                continue
+            }
+            contents = append(contents, &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{part}})
         }
+        // Skip system messages
+    }
+    return contents
+}
+
+// prepareChatSession creates a chat holding everything but the last turn as
+// history, and returns the parts of that last turn (user text or function results) to send
+func (g *GeminiAPI) prepareChatSession(ctx context.Context, messages []types.Message, config RequestConfig) (*genai.Chat, []*genai.Part, error) {
+    if len(messages) == 0 {
+        return nil, nil, fmt.Errorf("no messages to process")
+    }
+
+    model, err := g.resolveModel(config)
+    if err != nil {
+        return nil, nil, err
     }
     
-    // Create config with system instruction and function tools
-    var config *genai.GenerateContentConfig
-    if systemPrompt != "" {
-        config = &genai.GenerateContentConfig{
-            SystemInstruction: genai.NewContentFromText(systemPrompt, genai.RoleUser),
-            Tools: []*genai.Tool{
-                {
-                    FunctionDeclarations: []*genai.FunctionDeclaration{
-                        defineManifestFunction(),
-                    },
-                },
-            },
-        }
-    } else {
-        config = &genai.GenerateContentConfig{
-            Tools: []*genai.Tool{
-                {
-                    FunctionDeclarations: []*genai.FunctionDeclaration{
-                        defineManifestFunction(),
-                    },
-                },
-            },
-        }
+    // Convert ALL messages to genai Content format, the last one is what we send
+    contents := toGeminiContents(messages)
+    if len(contents) == 0 || contents[len(contents)-1].Role != genai.RoleUser {
+        return nil, nil, fmt.Errorf("conversation must end with a user turn")
     }
+    history := contents[:len(contents)-1]
+    lastParts := contents[len(contents)-1].Parts
+    
+    // Create config with system instruction and function tools
+    genConfig := geminiConfig(config)
     
     // Create chat with full conversation history and system instruction
-    chat, err := g.client.Chats.Create(ctx, "gemini-2.5-flash", config, history)
+    chat, err := g.client.Chats.Create(ctx, model, genConfig, history)
     if err != nil {
-        return nil, "", err
+        return nil, nil, wrapModelError(model, err)
     }
     
-    return chat, lastUserMessage, nil
+    return chat, lastParts, nil
 }
 
 
-func (g *GeminiAPI) GetResponse(messages []types.Message, systemPrompt string) (string, error) {
-    response, err := g.GetResponseWithFunctions(messages, systemPrompt)
+func (g *GeminiAPI) GetResponse(ctx context.Context, messages []types.Message, config RequestConfig) (string, error) {
+    response, err := g.GetResponseWithFunctions(ctx, messages, config)
     if err != nil {
         return "", err
     }
     return response.Text, nil
 }
 
-func (g *GeminiAPI) GetResponseWithFunctions(messages []types.Message, systemPrompt string) (*ResponseWithFunctions, error) {
-    ctx := context.Background()
-    
+func (g *GeminiAPI) GetResponseWithFunctions(ctx context.Context, messages []types.Message, config RequestConfig) (*ResponseWithFunctions, error) {
     if len(messages) == 0 {
         return &ResponseWithFunctions{Text: "No messages to process"}, nil
     }
-    
-    // Convert ALL messages to genai Content format
-    var contents []*genai.Content
-    for _, msg := range messages {
-        var role genai.Role
-        if msg.Role == "user" {
-            role = genai.RoleUser
-        } else if msg.Role == "assistant" {
-            role = genai.RoleModel
-        } else {
-            continue // Skip system messages
-        }
-        contents = append(contents, genai.NewContentFromText(msg.Content, role))
-    }
-    
-    // Create tools config
-    tools := []*genai.Tool{
-        {
-            FunctionDeclarations: []*genai.FunctionDeclaration{
-                defineManifestFunction(),
-            },
-        },
+
+    model, err := g.resolveModel(config)
+    if err != nil {
+        return nil, err
     }
     
-    config := &genai.GenerateContentConfig{
-        Tools: tools,
-    }
+    // Convert ALL messages to genai Content format
+    contents := toGeminiContents(messages)
     
-    if systemPrompt != "" {
-        config.SystemInstruction = genai.NewContentFromText(systemPrompt, genai.RoleUser)
-    }
+    genConfig := geminiConfig(config)
     
     // Use models.generate_content with full conversation
-    res, err := g.client.Models.GenerateContent(ctx, "gemini-2.5-flash", contents, config)
+    res, err := g.client.Models.GenerateContent(ctx, model, contents, genConfig)
     if err != nil {
-        return nil, err
+        return nil, wrapModelError(model, err)
     }
     
-    response := &ResponseWithFunctions{}
+    response := &ResponseWithFunctions{Usage: geminiUsage(res.UsageMetadata)}
     
     if len(res.Candidates) == 0 || res.Candidates[0].Content == nil || len(res.Candidates[0].Content.Parts) == 0 {
         response.Text = "No response received"
@@ -220,6 +263,7 @@ func (g *GeminiAPI) GetResponseWithFunctions(messages []types.Message, systemPro
             // Handle function call parts
             if part.FunctionCall != nil {
                 functionCall := FunctionCall{
+                    ID:   part.FunctionCall.ID,
                     Name: part.FunctionCall.Name,
                     Args: make(map[string]interface{}),
                 }
@@ -234,6 +278,8 @@ func (g *GeminiAPI) GetResponseWithFunctions(messages []types.Message, systemPro
         }
     }
     
+    ensureCallIDs(response.FunctionCalls)
+    
     if response.Text == "" && len(response.FunctionCalls) == 0 {
         response.Text = "No response received"
     }
@@ -241,7 +287,7 @@ func (g *GeminiAPI) GetResponseWithFunctions(messages []types.Message, systemPro
     return response, nil
 }
 
-func (g *GeminiAPI) GetStreamingResponse(messages []types.Message, systemPrompt string) (<-chan string, <-chan error) {
+func (g *GeminiAPI) GetStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan error) {
 	textChan := make(chan string)
 	errChan := make(chan error, 1)
 
@@ -249,16 +295,20 @@ func (g *GeminiAPI) GetStreamingResponse(messages []types.Message, systemPrompt
 		defer close(textChan)
 		defer close(errChan)
 
-		chat, lastUserMessage, err := g.prepareChatSession(messages, systemPrompt)
+		chat, lastParts, err := g.prepareChatSession(ctx, messages, config)
 		if err != nil {
 			errChan <- err
 			return
 		}
+		model, _ := g.resolveModel(config)
 
-		ctx := context.Background()
-		stream := chat.SendMessageStream(ctx, genai.Part{Text: lastUserMessage})
+		stream := chat.SendStream(ctx, lastParts...)
 
-		for chunk := range stream {
+		for chunk, err := range stream {
+			if err != nil {
+				errChan <- wrapModelError(model, err)
+				return
+			}
 			// Add safety checks to prevent segmentation faults
 			if chunk == nil {
 				continue
@@ -270,7 +320,9 @@ func (g *GeminiAPI) GetStreamingResponse(messages []types.Message, systemPrompt
 				
 				for _, part := range chunk.Candidates[0].Content.Parts {
 					if part.Text != "" {
-						textChan <- part.Text
+						if !sendText(ctx, textChan, part.Text) {
+							return
+						}
 					}
 				}
 			}
@@ -280,31 +332,41 @@ func (g *GeminiAPI) GetStreamingResponse(messages []types.Message, systemPrompt
 	return textChan, errChan
 }
 
-func (g *GeminiAPI) GetEnhancedStreamingResponse(messages []types.Message, systemPrompt string) (<-chan string, <-chan []FunctionCall, <-chan error) {
+func (g *GeminiAPI) GetEnhancedStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan StreamResult, <-chan error) {
 	textChan := make(chan string)
-	funcChan := make(chan []FunctionCall)
+	// buffered so the result is ready by the time textChan closes
+	resultChan := make(chan StreamResult, 1)
 	errChan := make(chan error, 1)
 
 	go func() {
 		defer close(textChan)
-		defer close(funcChan)
+		defer close(resultChan)
 		defer close(errChan)
 
-		chat, lastUserMessage, err := g.prepareChatSession(messages, systemPrompt)
+		chat, lastParts, err := g.prepareChatSession(ctx, messages, config)
 		if err != nil {
 			errChan <- err
 			return
 		}
+		model, _ := g.resolveModel(config)
 
-		ctx := context.Background()
-		stream := chat.SendMessageStream(ctx, genai.Part{Text: lastUserMessage})
+		stream := chat.SendStream(ctx, lastParts...)
 
 		var functionCalls []FunctionCall
+		var usage Usage
 		
-		for chunk := range stream {
+		for chunk, err := range stream {
+			if err != nil {
+				errChan <- wrapModelError(model, err)
+				return
+			}
 			if chunk == nil {
 				continue
 			}
+			// every chunk carries the running totals
+			if chunk.UsageMetadata != nil {
+				usage = geminiUsage(chunk.UsageMetadata)
+			}
 			if len(chunk.Candidates) > 0 && 
 			   chunk.Candidates[0] != nil && 
 			   chunk.Candidates[0].Content != nil &&
@@ -313,12 +375,15 @@ func (g *GeminiAPI) GetEnhancedStreamingResponse(messages []types.Message, syste
 				for _, part := range chunk.Candidates[0].Content.Parts {
 					// Handle text parts
 					if part.Text != "" {
-						textChan <- part.Text
+						if !sendText(ctx, textChan, part.Text) {
+							return
+						}
 					}
 					
 					// Handle function call parts
 					if part.FunctionCall != nil {
 						functionCall := FunctionCall{
+							ID:   part.FunctionCall.ID,
 							Name: part.FunctionCall.Name,
 							Args: make(map[string]interface{}),
 						}
@@ -334,11 +399,9 @@ func (g *GeminiAPI) GetEnhancedStreamingResponse(messages []types.Message, syste
 			}
 		}
 		
-		// Send function calls at the end if any were found
-		if len(functionCalls) > 0 {
-			funcChan <- functionCalls
-		}
+		// Send function calls and usage once the stream is done
+		resultChan <- StreamResult{FunctionCalls: ensureCallIDs(functionCalls), Usage: usage}
 	}()
 
-	return textChan, funcChan, errChan
+	return textChan, resultChan, errChan
 }
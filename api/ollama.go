@@ -0,0 +1,350 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/curator4/io-tui/types"
+)
+
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaAPI talks to a local Ollama-style inference server
+type OllamaAPI struct {
+	baseURL string
+	client  *http.Client
+}
+
+// wire format types for /api/chat and /api/tags
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []ollamaTool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+type ollamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	TopK        *int     `json:"top_k,omitempty"`
+	NumPredict  *int     `json:"num_predict,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error,omitempty"`
+	// token counts, only set on the final message
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"`
+	EvalCount       int `json:"eval_count,omitempty"`
+}
+
+type ollamaTags struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// NewOllamaAPI creates a client for the server at baseURL
+func NewOllamaAPI(baseURL string) *OllamaAPI {
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return &OllamaAPI{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+// NewOllamaAPIFromEnv uses OLLAMA_HOST like the ollama cli does
+func NewOllamaAPIFromEnv() (*OllamaAPI, error) {
+	return NewOllamaAPI(os.Getenv("OLLAMA_HOST")), nil
+}
+
+// ListModels asks the server which models are installed
+func (o *OllamaAPI) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama not reachable at %s: %w", o.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama: bad status: %s", resp.Status)
+	}
+
+	var tags ollamaTags
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	var models []string
+	for _, model := range tags.Models {
+		models = append(models, model.Name)
+	}
+	return models, nil
+}
+
+// resolveModel picks the model for a request, falling back to the default
+func (o *OllamaAPI) resolveModel(config RequestConfig) string {
+	if config.Model == "" {
+		return AvailableAPIs["ollama"].DefaultModel
+	}
+	return config.Model
+}
+
+// buildRequest converts our messages and config into an /api/chat request
+func (o *OllamaAPI) buildRequest(messages []types.Message, config RequestConfig, stream bool) ollamaRequest {
+	req := ollamaRequest{
+		Model:  o.resolveModel(config),
+		Stream: stream,
+	}
+	if params := config.Params; params.String() != "" {
+		req.Options = &ollamaOptions{
+			Temperature: params.Temperature,
+			TopP:        params.TopP,
+			TopK:        params.TopK,
+			NumPredict:  params.MaxOutputTokens,
+			Stop:        params.StopSequences,
+			Seed:        params.Seed,
+		}
+	}
+
+	if config.SystemPrompt != "" {
+		req.Messages = append(req.Messages, ollamaMessage{Role: "system", Content: config.SystemPrompt})
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			req.Messages = append(req.Messages, ollamaMessage{Role: "user", Content: msg.Content})
+		case "assistant":
+			message := ollamaMessage{Role: "assistant", Content: msg.Content}
+			for _, call := range msg.FunctionCalls {
+				var toolCall ollamaToolCall
+				toolCall.Function.Name = call.Name
+				toolCall.Function.Arguments = call.Args
+				message.ToolCalls = append(message.ToolCalls, toolCall)
+			}
+			req.Messages = append(req.Messages, message)
+		case "tool":
+			req.Messages = append(req.Messages, ollamaMessage{Role: "tool", Content: msg.Content, ToolName: msg.FunctionName})
+		}
+	}
+
+	for _, decl := range config.Functions {
+		req.Tools = append(req.Tools, ollamaTool{
+			Type: "function",
+			Function: openAIFunction{
+				Name:        decl.Name,
+				Description: decl.Description,
+				Parameters:  decl.Parameters,
+			},
+		})
+	}
+
+	return req
+}
+
+// post sends a chat request and checks the status code
+func (o *OllamaAPI) post(ctx context.Context, body ollamaRequest) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/chat", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama not reachable at %s: %w", o.baseURL, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+
+		message := strings.TrimSpace(string(raw))
+		var decoded ollamaResponse
+		if json.Unmarshal(raw, &decoded) == nil && decoded.Error != "" {
+			message = decoded.Error
+		}
+
+		err := fmt.Errorf("ollama: %s: %s", resp.Status, message)
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, &ModelError{API: "ollama", Model: body.Model, Err: err}
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// toFunctionCalls converts ollama tool calls, which carry decoded arguments
+func (o *OllamaAPI) toFunctionCalls(toolCalls []ollamaToolCall) []FunctionCall {
+	var functionCalls []FunctionCall
+	for _, toolCall := range toolCalls {
+		functionCall := FunctionCall{
+			Name: toolCall.Function.Name,
+			Args: make(map[string]interface{}),
+		}
+		for key, value := range toolCall.Function.Arguments {
+			functionCall.Args[key] = value
+		}
+		functionCalls = append(functionCalls, functionCall)
+	}
+	return ensureCallIDs(functionCalls)
+}
+
+func (o *OllamaAPI) GetResponse(ctx context.Context, messages []types.Message, config RequestConfig) (string, error) {
+	response, err := o.GetResponseWithFunctions(ctx, messages, config)
+	if err != nil {
+		return "", err
+	}
+	return response.Text, nil
+}
+
+func (o *OllamaAPI) GetResponseWithFunctions(ctx context.Context, messages []types.Message, config RequestConfig) (*ResponseWithFunctions, error) {
+	if len(messages) == 0 {
+		return &ResponseWithFunctions{Text: "No messages to process"}, nil
+	}
+
+	resp, err := o.post(ctx, o.buildRequest(messages, config, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	response := &ResponseWithFunctions{
+		Text:          decoded.Message.Content,
+		FunctionCalls: o.toFunctionCalls(decoded.Message.ToolCalls),
+		Usage:         Usage{PromptTokens: decoded.PromptEvalCount, CompletionTokens: decoded.EvalCount},
+	}
+
+	if response.Text == "" && len(response.FunctionCalls) == 0 {
+		response.Text = "No response received"
+	}
+
+	return response, nil
+}
+
+func (o *OllamaAPI) GetStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan error) {
+	textChan, _, errChan := o.GetEnhancedStreamingResponse(ctx, messages, config)
+	return textChan, errChan
+}
+
+func (o *OllamaAPI) GetEnhancedStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan StreamResult, <-chan error) {
+	textChan := make(chan string)
+	// buffered so the result is ready by the time textChan closes
+	resultChan := make(chan StreamResult, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(textChan)
+		defer close(resultChan)
+		defer close(errChan)
+
+		if len(messages) == 0 {
+			errChan <- fmt.Errorf("no messages to process")
+			return
+		}
+
+		resp, err := o.post(ctx, o.buildRequest(messages, config, true))
+		if err != nil {
+			errChan <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		var functionCalls []FunctionCall
+		var usage Usage
+
+		// one JSON object per line
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+
+			var chunk ollamaResponse
+			if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+				errChan <- fmt.Errorf("failed to decode stream chunk: %w", err)
+				return
+			}
+			if chunk.Error != "" {
+				errChan <- fmt.Errorf("ollama: %s", chunk.Error)
+				return
+			}
+
+			if chunk.Message.Content != "" {
+				if !sendText(ctx, textChan, chunk.Message.Content) {
+					return
+				}
+			}
+			functionCalls = append(functionCalls, o.toFunctionCalls(chunk.Message.ToolCalls)...)
+
+			if chunk.Done {
+				usage = Usage{PromptTokens: chunk.PromptEvalCount, CompletionTokens: chunk.EvalCount}
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			errChan <- err
+			return
+		}
+
+		resultChan <- StreamResult{FunctionCalls: functionCalls, Usage: usage}
+	}()
+
+	return textChan, resultChan, errChan
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/curator4/io-tui/types"
+)
+
+// drainStream collects everything an enhanced stream delivers
+func drainStream(textChan <-chan string, resultChan <-chan StreamResult, errChan <-chan error) (string, StreamResult, error) {
+	var text strings.Builder
+	for chunk := range textChan {
+		text.WriteString(chunk)
+	}
+	if err := <-errChan; err != nil {
+		return text.String(), StreamResult{}, err
+	}
+	return text.String(), <-resultChan, nil
+}
+
+// ollamaServer answers /api/chat with the given NDJSON lines and records the request
+func ollamaServer(t *testing.T, lines ...string) (*httptest.Server, *ollamaRequest) {
+	t.Helper()
+	var received ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		for _, line := range lines {
+			w.Write([]byte(line + "\n"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+// helloMessages is a one message conversation for requests that only need some input
+var helloMessages = []types.Message{{Role: "user", Content: "hi"}}
+
+func TestOllamaStreamText(t *testing.T) {
+	server, received := ollamaServer(t,
+		`{"message":{"role":"assistant","content":"Hel"},"done":false}`,
+		``,
+		`{"message":{"role":"assistant","content":"lo"},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":12,"eval_count":3}`,
+	)
+
+	o := NewOllamaAPI(server.URL)
+	text, result, err := drainStream(o.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{Model: "llama3.2", SystemPrompt: "be brief"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hello" {
+		t.Errorf("text = %q, want %q", text, "Hello")
+	}
+	if result.Usage != (Usage{PromptTokens: 12, CompletionTokens: 3}) {
+		t.Errorf("usage = %+v", result.Usage)
+	}
+	if len(result.FunctionCalls) != 0 {
+		t.Errorf("unexpected function calls %+v", result.FunctionCalls)
+	}
+
+	if !received.Stream || received.Model != "llama3.2" {
+		t.Errorf("request stream=%v model=%q", received.Stream, received.Model)
+	}
+	if len(received.Messages) != 2 || received.Messages[0].Role != "system" || received.Messages[1].Content != "hi" {
+		t.Errorf("request messages = %+v", received.Messages)
+	}
+}
+
+func TestOllamaStreamToolCalls(t *testing.T) {
+	server, _ := ollamaServer(t,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"remember","arguments":{"content":"likes tea"}}}]},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":5,"eval_count":7}`,
+	)
+
+	o := NewOllamaAPI(server.URL)
+	text, result, err := drainStream(o.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "" {
+		t.Errorf("text = %q, want none", text)
+	}
+	if len(result.FunctionCalls) != 1 {
+		t.Fatalf("function calls = %+v, want one", result.FunctionCalls)
+	}
+	call := result.FunctionCalls[0]
+	if call.Name != "remember" || call.Args["content"] != "likes tea" || call.ID == "" {
+		t.Errorf("function call = %+v", call)
+	}
+	if result.Usage != (Usage{PromptTokens: 5, CompletionTokens: 7}) {
+		t.Errorf("usage = %+v", result.Usage)
+	}
+}
+
+func TestOllamaStreamError(t *testing.T) {
+	server, _ := ollamaServer(t,
+		`{"message":{"role":"assistant","content":"partial"},"done":false}`,
+		`{"error":"model runner crashed"}`,
+	)
+
+	o := NewOllamaAPI(server.URL)
+	text, _, err := drainStream(o.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{}))
+	if err == nil || !strings.Contains(err.Error(), "model runner crashed") {
+		t.Fatalf("err = %v, want the stream's error", err)
+	}
+	if text != "partial" {
+		t.Errorf("text = %q, want the text before the error", text)
+	}
+}
+
+func TestOllamaUnknownModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"nope\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	o := NewOllamaAPI(server.URL)
+	_, err := o.GetResponseWithFunctions(context.Background(), helloMessages, RequestConfig{Model: "nope"})
+	var modelErr *ModelError
+	if !errors.As(err, &modelErr) || modelErr.Model != "nope" {
+		t.Fatalf("err = %v, want a ModelError for nope", err)
+	}
+}
+
+func TestOllamaListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"models":[{"name":"llama3.2:latest"},{"name":"qwen2.5:7b"}]}`))
+	}))
+	defer server.Close()
+
+	models, err := NewOllamaAPI(server.URL).ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(models, []string{"llama3.2:latest", "qwen2.5:7b"}) {
+		t.Errorf("models = %v", models)
+	}
+}
+
+func TestOllamaListModelsUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	if _, err := NewOllamaAPI(server.URL).ListModels(context.Background()); err == nil {
+		t.Fatal("want an error for a server that is down")
+	}
+}
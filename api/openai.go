@@ -0,0 +1,411 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/curator4/io-tui/types"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIAPI talks to any server implementing the OpenAI chat completions
+// wire format (OpenAI itself, gateways, local inference servers)
+type OpenAIAPI struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// wire format types for /v1/chat/completions
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments,omitempty"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type openAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Tools       []openAITool    `json:"tools,omitempty"`
+	Stream      bool            `json:"stream,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
+	MaxTokens   *int            `json:"max_tokens,omitempty"`
+	Stop        []string        `json:"stop,omitempty"`
+	Seed        *int            `json:"seed,omitempty"`
+	// asks for a final chunk carrying usage when streaming
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		Delta        openAIMessage `json:"delta"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage,omitempty"`
+	Error *openAIError `json:"error,omitempty"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    any    `json:"code"`
+}
+
+// NewOpenAIAPI creates a client for the chat completions endpoint under baseURL
+// (e.g. "https://api.openai.com/v1" or "http://localhost:8080/v1")
+func NewOpenAIAPI(baseURL, apiKey string) *OpenAIAPI {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAIAPI{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}
+}
+
+// NewOpenAIAPIFromEnv configures the client from OPENAI_API_KEY and OPENAI_BASE_URL.
+// A key is only required when talking to the official endpoint.
+func NewOpenAIAPIFromEnv() (*OpenAIAPI, error) {
+	return NewOpenAIAPIFromConfig("", "")
+}
+
+// NewOpenAIAPIFromConfig uses baseURL and apiKey when set, falling back to
+// the environment otherwise
+func NewOpenAIAPIFromConfig(baseURL, apiKey string) (*OpenAIAPI, error) {
+	if baseURL == "" {
+		baseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	if apiKey == "" && (baseURL == "" || strings.TrimSuffix(baseURL, "/") == defaultOpenAIBaseURL) {
+		return nil, fmt.Errorf("No API key found. Export OPENAI_API_KEY=your_key, or point OPENAI_BASE_URL at a compatible server")
+	}
+
+	return NewOpenAIAPI(baseURL, apiKey), nil
+}
+
+// resolveModel picks the model for a request, falling back to the default
+func (o *OpenAIAPI) resolveModel(config RequestConfig) string {
+	if config.Model == "" {
+		return AvailableAPIs["openai"].DefaultModel
+	}
+	return config.Model
+}
+
+// buildRequest converts our messages and config into a chat completions request
+func (o *OpenAIAPI) buildRequest(messages []types.Message, config RequestConfig, stream bool) openAIRequest {
+	// top_k has no equivalent in chat completions
+	req := openAIRequest{
+		Model:       o.resolveModel(config),
+		Stream:      stream,
+		Temperature: config.Params.Temperature,
+		TopP:        config.Params.TopP,
+		MaxTokens:   config.Params.MaxOutputTokens,
+		Stop:        config.Params.StopSequences,
+		Seed:        config.Params.Seed,
+	}
+	if stream {
+		req.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	if config.SystemPrompt != "" {
+		req.Messages = append(req.Messages, openAIMessage{Role: "system", Content: config.SystemPrompt})
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			req.Messages = append(req.Messages, openAIMessage{Role: "user", Content: msg.Content})
+		case "assistant":
+			message := openAIMessage{Role: "assistant", Content: msg.Content}
+			for _, call := range msg.FunctionCalls {
+				arguments, _ := json.Marshal(call.Args)
+				toolCall := openAIToolCall{ID: call.ID, Type: "function"}
+				toolCall.Function.Name = call.Name
+				toolCall.Function.Arguments = string(arguments)
+				message.ToolCalls = append(message.ToolCalls, toolCall)
+			}
+			req.Messages = append(req.Messages, message)
+		case "tool":
+			req.Messages = append(req.Messages, openAIMessage{Role: "tool", Content: msg.Content, ToolCallID: msg.FunctionCallID})
+		}
+	}
+
+	for _, decl := range config.Functions {
+		req.Tools = append(req.Tools, openAITool{
+			Type: "function",
+			Function: openAIFunction{
+				Name:        decl.Name,
+				Description: decl.Description,
+				Parameters:  decl.Parameters,
+			},
+		})
+	}
+
+	return req
+}
+
+// post sends a chat completions request and checks the status code
+func (o *OpenAIAPI) post(ctx context.Context, body openAIRequest) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	if body.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, o.statusError(body.Model, resp)
+	}
+	return resp, nil
+}
+
+// statusError decodes an error body, reporting model rejections as ModelError
+func (o *OpenAIAPI) statusError(model string, resp *http.Response) error {
+	raw, _ := io.ReadAll(resp.Body)
+
+	var decoded openAIResponse
+	message := strings.TrimSpace(string(raw))
+	code := ""
+	if json.Unmarshal(raw, &decoded) == nil && decoded.Error != nil {
+		message = decoded.Error.Message
+		code = fmt.Sprint(decoded.Error.Code)
+	}
+
+	err := fmt.Errorf("openai: %s: %s", resp.Status, message)
+	if resp.StatusCode == http.StatusNotFound || code == "model_not_found" {
+		return &ModelError{API: "openai", Model: model, Err: err}
+	}
+	return err
+}
+
+// toFunctionCall decodes the JSON encoded arguments of a tool call
+func (o *OpenAIAPI) toFunctionCall(id, name, arguments string) FunctionCall {
+	functionCall := FunctionCall{
+		ID:   id,
+		Name: name,
+		Args: make(map[string]interface{}),
+	}
+	if strings.TrimSpace(arguments) != "" {
+		json.Unmarshal([]byte(arguments), &functionCall.Args)
+	}
+	return functionCall
+}
+
+func (o *OpenAIAPI) GetResponse(ctx context.Context, messages []types.Message, config RequestConfig) (string, error) {
+	response, err := o.GetResponseWithFunctions(ctx, messages, config)
+	if err != nil {
+		return "", err
+	}
+	return response.Text, nil
+}
+
+func (o *OpenAIAPI) GetResponseWithFunctions(ctx context.Context, messages []types.Message, config RequestConfig) (*ResponseWithFunctions, error) {
+	if len(messages) == 0 {
+		return &ResponseWithFunctions{Text: "No messages to process"}, nil
+	}
+
+	resp, err := o.post(ctx, o.buildRequest(messages, config, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	response := &ResponseWithFunctions{}
+	if decoded.Usage != nil {
+		response.Usage = Usage{PromptTokens: decoded.Usage.PromptTokens, CompletionTokens: decoded.Usage.CompletionTokens}
+	}
+	if len(decoded.Choices) > 0 {
+		message := decoded.Choices[0].Message
+		response.Text = message.Content
+		for _, toolCall := range message.ToolCalls {
+			response.FunctionCalls = append(response.FunctionCalls, o.toFunctionCall(toolCall.ID, toolCall.Function.Name, toolCall.Function.Arguments))
+		}
+		ensureCallIDs(response.FunctionCalls)
+	}
+
+	if response.Text == "" && len(response.FunctionCalls) == 0 {
+		response.Text = "No response received"
+	}
+
+	return response, nil
+}
+
+func (o *OpenAIAPI) GetStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan error) {
+	textChan, _, errChan := o.GetEnhancedStreamingResponse(ctx, messages, config)
+	return textChan, errChan
+}
+
+func (o *OpenAIAPI) GetEnhancedStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan StreamResult, <-chan error) {
+	textChan := make(chan string)
+	// buffered so the result is ready by the time textChan closes
+	resultChan := make(chan StreamResult, 1)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(textChan)
+		defer close(resultChan)
+		defer close(errChan)
+
+		if len(messages) == 0 {
+			errChan <- fmt.Errorf("no messages to process")
+			return
+		}
+
+		resp, err := o.post(ctx, o.buildRequest(messages, config, true))
+		if err != nil {
+			errChan <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		// tool calls arrive as fragments keyed by index
+		type pendingCall struct {
+			id        string
+			name      string
+			arguments strings.Builder
+		}
+		pending := make(map[int]*pendingCall)
+		var usage Usage
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				break
+			}
+
+			var chunk openAIResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				errChan <- fmt.Errorf("failed to decode stream chunk: %w", err)
+				return
+			}
+			if chunk.Error != nil {
+				errChan <- fmt.Errorf("openai: %s", chunk.Error.Message)
+				return
+			}
+			// usage comes in a last chunk without choices
+			if chunk.Usage != nil {
+				usage = Usage{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+			}
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+
+			delta := chunk.Choices[0].Delta
+			if delta.Content != "" {
+				if !sendText(ctx, textChan, delta.Content) {
+					return
+				}
+			}
+			for i, toolCall := range delta.ToolCalls {
+				index := i
+				if toolCall.Index != nil {
+					index = *toolCall.Index
+				}
+				call, ok := pending[index]
+				if !ok {
+					call = &pendingCall{}
+					pending[index] = call
+				}
+				if toolCall.ID != "" {
+					call.id = toolCall.ID
+				}
+				if toolCall.Function.Name != "" {
+					call.name = toolCall.Function.Name
+				}
+				call.arguments.WriteString(toolCall.Function.Arguments)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			errChan <- err
+			return
+		}
+
+		// Send function calls in the order the model issued them
+		indexes := make([]int, 0, len(pending))
+		for index := range pending {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+
+		var functionCalls []FunctionCall
+		for _, index := range indexes {
+			call := pending[index]
+			functionCalls = append(functionCalls, o.toFunctionCall(call.id, call.name, call.arguments.String()))
+		}
+		resultChan <- StreamResult{FunctionCalls: ensureCallIDs(functionCalls), Usage: usage}
+	}()
+
+	return textChan, resultChan, errChan
+}
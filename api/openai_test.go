@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// openAIServer answers /chat/completions with body and records the request
+func openAIServer(t *testing.T, status int, body string) (*httptest.Server, *openAIRequest) {
+	t.Helper()
+	var received openAIRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestOpenAIResponse(t *testing.T) {
+	server, received := openAIServer(t, http.StatusOK, `{
+		"choices": [{"message": {"role": "assistant", "content": "Hello there"}, "finish_reason": "stop"}],
+		"usage": {"prompt_tokens": 9, "completion_tokens": 2}
+	}`)
+
+	o := NewOpenAIAPI(server.URL+"/v1/", "test-key")
+	response, err := o.GetResponseWithFunctions(context.Background(), helloMessages, RequestConfig{Model: "gpt-4o-mini", SystemPrompt: "be brief"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Text != "Hello there" || len(response.FunctionCalls) != 0 {
+		t.Errorf("response = %+v", response)
+	}
+	if response.Usage != (Usage{PromptTokens: 9, CompletionTokens: 2}) {
+		t.Errorf("usage = %+v", response.Usage)
+	}
+
+	if received.Stream || received.Model != "gpt-4o-mini" {
+		t.Errorf("request stream=%v model=%q", received.Stream, received.Model)
+	}
+	if len(received.Messages) != 2 || received.Messages[0].Role != "system" || received.Messages[1].Content != "hi" {
+		t.Errorf("request messages = %+v", received.Messages)
+	}
+}
+
+func TestOpenAIStreamText(t *testing.T) {
+	server, received := openAIServer(t, http.StatusOK, `data: {"choices":[{"delta":{"role":"assistant","content":""}}]}
+
+data: {"choices":[{"delta":{"content":"Hel"}}]}
+
+: keep-alive
+
+data: {"choices":[{"delta":{"content":"lo"}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"stop"}]}
+
+data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3}}
+
+data: [DONE]
+`)
+
+	o := NewOpenAIAPI(server.URL+"/v1", "test-key")
+	text, result, err := drainStream(o.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hello" {
+		t.Errorf("text = %q, want %q", text, "Hello")
+	}
+	if result.Usage != (Usage{PromptTokens: 12, CompletionTokens: 3}) {
+		t.Errorf("usage = %+v, want the final usage-only chunk's", result.Usage)
+	}
+	if !received.Stream || received.StreamOptions == nil || !received.StreamOptions.IncludeUsage {
+		t.Errorf("request stream=%v stream_options=%+v", received.Stream, received.StreamOptions)
+	}
+}
+
+func TestOpenAIStreamToolCalls(t *testing.T) {
+	// Two calls, their arguments split over chunks and interleaved by index
+	server, _ := openAIServer(t, http.StatusOK, `data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"remember","arguments":""}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"manifest","arguments":"{\"name\":"}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"content\":"}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"likes tea\"}"}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":1,"function":{"arguments":"\"Pikachu\"}"}}]}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+`)
+
+	o := NewOpenAIAPI(server.URL+"/v1", "test-key")
+	text, result, err := drainStream(o.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "" {
+		t.Errorf("text = %q, want none", text)
+	}
+	if len(result.FunctionCalls) != 2 {
+		t.Fatalf("function calls = %+v, want two", result.FunctionCalls)
+	}
+	first, second := result.FunctionCalls[0], result.FunctionCalls[1]
+	if first.ID != "call_a" || first.Name != "remember" || first.Args["content"] != "likes tea" {
+		t.Errorf("first call = %+v", first)
+	}
+	if second.ID != "call_b" || second.Name != "manifest" || second.Args["name"] != "Pikachu" {
+		t.Errorf("second call = %+v", second)
+	}
+}
+
+func TestOpenAIUnknownModel(t *testing.T) {
+	server, _ := openAIServer(t, http.StatusNotFound, `{"error":{"message":"The model 'gpt-nope' does not exist","type":"invalid_request_error","code":"model_not_found"}}`)
+
+	o := NewOpenAIAPI(server.URL+"/v1", "test-key")
+	_, err := o.GetResponseWithFunctions(context.Background(), helloMessages, RequestConfig{Model: "gpt-nope"})
+	var modelErr *ModelError
+	if !errors.As(err, &modelErr) || modelErr.Model != "gpt-nope" || modelErr.API != "openai" {
+		t.Fatalf("err = %v, want a ModelError for gpt-nope", err)
+	}
+
+	// Streams report the rejection the same way
+	_, _, err = drainStream(o.GetEnhancedStreamingResponse(context.Background(), helloMessages, RequestConfig{Model: "gpt-nope"}))
+	if !errors.As(err, &modelErr) {
+		t.Fatalf("stream err = %v, want a ModelError", err)
+	}
+}
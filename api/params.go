@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Params are the sampling settings of an AI. Unset (nil/empty) fields are
+// left out of requests so the provider default applies. Providers ignore
+// settings they have no equivalent for.
+type Params struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	TopK            *int     `json:"top_k,omitempty"`
+	MaxOutputTokens *int     `json:"max_tokens,omitempty"`
+	StopSequences   []string `json:"stop,omitempty"`
+	Seed            *int     `json:"seed,omitempty"`
+}
+
+// ParamNames lists the names accepted by Set, in display order
+var ParamNames = []string{"temperature", "top_p", "top_k", "max_tokens", "stop", "seed"}
+
+// DecodeParams reads params stored as JSON, an empty string means no params
+func DecodeParams(data string) (Params, error) {
+	var params Params
+	if strings.TrimSpace(data) == "" {
+		return params, nil
+	}
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		return Params{}, fmt.Errorf("invalid params: %w", err)
+	}
+	return params, nil
+}
+
+// Encode serializes the params for storage
+func (p Params) Encode() (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode params: %w", err)
+	}
+	return string(data), nil
+}
+
+// Set parses and assigns one param by name. No values, or "default",
+// resets it. stop takes one or more sequences.
+func (p *Params) Set(name string, values ...string) error {
+	reset := len(values) == 0 || (len(values) == 1 && values[0] == "default")
+	value := strings.Join(values, " ")
+
+	switch name {
+	case "temperature":
+		if reset {
+			p.Temperature = nil
+			return nil
+		}
+		f, err := parseFloat(name, value, 0, 2)
+		if err != nil {
+			return err
+		}
+		p.Temperature = &f
+	case "top_p":
+		if reset {
+			p.TopP = nil
+			return nil
+		}
+		f, err := parseFloat(name, value, 0, 1)
+		if err != nil {
+			return err
+		}
+		p.TopP = &f
+	case "top_k":
+		if reset {
+			p.TopK = nil
+			return nil
+		}
+		n, err := parsePositiveInt(name, value)
+		if err != nil {
+			return err
+		}
+		p.TopK = &n
+	case "max_tokens":
+		if reset {
+			p.MaxOutputTokens = nil
+			return nil
+		}
+		n, err := parsePositiveInt(name, value)
+		if err != nil {
+			return err
+		}
+		p.MaxOutputTokens = &n
+	case "stop":
+		if reset {
+			p.StopSequences = nil
+			return nil
+		}
+		p.StopSequences = values
+	case "seed":
+		if reset {
+			p.Seed = nil
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("seed must be a whole number, got %q", value)
+		}
+		p.Seed = &n
+	default:
+		return fmt.Errorf("unknown param %q (available: %s)", name, strings.Join(ParamNames, ", "))
+	}
+	return nil
+}
+
+// String shows the params that are set, e.g. "temperature=0.7 top_k=40"
+func (p Params) String() string {
+	return strings.Join(p.Fields(), " ")
+}
+
+// Fields lists the params that are set as name=value pairs
+func (p Params) Fields() []string {
+	var parts []string
+	if p.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("temperature=%g", *p.Temperature))
+	}
+	if p.TopP != nil {
+		parts = append(parts, fmt.Sprintf("top_p=%g", *p.TopP))
+	}
+	if p.TopK != nil {
+		parts = append(parts, fmt.Sprintf("top_k=%d", *p.TopK))
+	}
+	if p.MaxOutputTokens != nil {
+		parts = append(parts, fmt.Sprintf("max_tokens=%d", *p.MaxOutputTokens))
+	}
+	if len(p.StopSequences) > 0 {
+		parts = append(parts, fmt.Sprintf("stop=%q", p.StopSequences))
+	}
+	if p.Seed != nil {
+		parts = append(parts, fmt.Sprintf("seed=%d", *p.Seed))
+	}
+	return parts
+}
+
+func parseFloat(name, value string, min, max float64) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || f < min || f > max {
+		return 0, fmt.Errorf("%s must be a number between %g and %g, got %q", name, min, max, value)
+	}
+	return f, nil
+}
+
+func parsePositiveInt(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive whole number, got %q", name, value)
+	}
+	return n, nil
+}
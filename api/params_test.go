@@ -0,0 +1,51 @@
+package api
+
+import "testing"
+
+func TestParamsSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		wantErr bool
+	}{
+		{"temperature", []string{"0.7"}, false},
+		{"temperature", []string{"2"}, false},
+		{"temperature", []string{"2.5"}, true},
+		{"temperature", []string{"-1"}, true},
+		{"temperature", []string{"NaN"}, true},
+		{"temperature", []string{"warm"}, true},
+		{"top_p", []string{"nan"}, true},
+		{"top_p", []string{"Inf"}, true},
+		{"top_k", []string{"0"}, true},
+		{"max_tokens", []string{"512"}, false},
+		{"seed", []string{"-3"}, false},
+		{"stop", []string{"END", "STOP"}, false},
+		{"mood", []string{"1"}, true},
+	}
+	for _, tt := range tests {
+		var p Params
+		err := p.Set(tt.name, tt.values...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q, %q) err = %v, want error %v", tt.name, tt.values, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if _, err := p.Encode(); err != nil {
+				t.Errorf("Set(%q, %q) stored params that don't encode: %v", tt.name, tt.values, err)
+			}
+		}
+	}
+}
+
+func TestParamsReset(t *testing.T) {
+	var p Params
+	if err := p.Set("temperature", "0.5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set("temperature", "default"); err != nil {
+		t.Fatal(err)
+	}
+	if p.Temperature != nil {
+		t.Errorf("temperature = %v after reset, want unset", *p.Temperature)
+	}
+}
@@ -1,10 +1,26 @@
 package api
 
-// APIInfo contains metadata about an API provider
+// APIInfo contains metadata about an API provider.
+// Models is empty for providers that implement ModelLister.
 type APIInfo struct {
 	Name         string
 	DefaultModel string
 	Models       []string
+	// Pricing per model, models without an entry are treated as free
+	// (e.g. local inference)
+	Pricing map[string]Price
+	// TokenBudgets is roughly how many prompt tokens a model should get
+	// before older turns are compacted into a summary. It sits well below
+	// the context window to keep long conversations cheap.
+	TokenBudgets map[string]int
+	// DefaultBudget applies to models without an entry, 0 never compacts
+	DefaultBudget int
+}
+
+// Price is what a model costs in USD per million tokens
+type Price struct {
+	Input  float64
+	Output float64
 }
 
 // AvailableAPIs maps API names to their configuration
@@ -13,5 +29,68 @@ var AvailableAPIs = map[string]APIInfo{
 		Name:         "Google Gemini",
 		DefaultModel: "gemini-2.5-flash-lite",
 		Models:       []string{"gemini-2.5-flash-lite", "gemini-2.5-flash"},
+		Pricing: map[string]Price{
+			"gemini-2.5-flash-lite": {Input: 0.10, Output: 0.40},
+			"gemini-2.5-flash":      {Input: 0.30, Output: 2.50},
+		},
+		DefaultBudget: 100_000,
+	},
+	"openai": {
+		Name:         "OpenAI",
+		DefaultModel: "gpt-4o-mini",
+		Models:       []string{"gpt-4o-mini", "gpt-4o", "gpt-4.1-mini", "gpt-4.1"},
+		Pricing: map[string]Price{
+			"gpt-4o-mini":  {Input: 0.15, Output: 0.60},
+			"gpt-4o":       {Input: 2.50, Output: 10.00},
+			"gpt-4.1-mini": {Input: 0.40, Output: 1.60},
+			"gpt-4.1":      {Input: 2.00, Output: 8.00},
+		},
+		TokenBudgets: map[string]int{
+			"gpt-4.1-mini": 100_000,
+			"gpt-4.1":      100_000,
+		},
+		DefaultBudget: 60_000,
+	},
+	"anthropic": {
+		Name:         "Anthropic",
+		DefaultModel: "claude-3-5-haiku-latest",
+		Models:       []string{"claude-3-5-haiku-latest", "claude-sonnet-4-0", "claude-opus-4-0"},
+		Pricing: map[string]Price{
+			"claude-3-5-haiku-latest": {Input: 0.80, Output: 4.00},
+			"claude-sonnet-4-0":       {Input: 3.00, Output: 15.00},
+			"claude-opus-4-0":         {Input: 15.00, Output: 75.00},
+		},
+		TokenBudgets: map[string]int{
+			// Expensive enough to compact early
+			"claude-opus-4-0": 40_000,
+		},
+		DefaultBudget: 100_000,
+	},
+	"ollama": {
+		Name:         "Ollama (local)",
+		DefaultModel: "llama3.2",
+		// Ollama's default context window is small, older turns get cut off
+		DefaultBudget: 3_000,
 	},
-}
\ No newline at end of file
+}
+
+// EstimateCost prices a usage with the pricing table, ok is false when the
+// model has no known price
+func EstimateCost(apiName, model string, usage Usage) (cost float64, ok bool) {
+	price, ok := AvailableAPIs[apiName].Pricing[model]
+	if !ok {
+		return 0, false
+	}
+	cost = float64(usage.PromptTokens)*price.Input/1e6 + float64(usage.CompletionTokens)*price.Output/1e6
+	return cost, true
+}
+
+// TokenBudget is the prompt size at which a conversation with the model gets
+// compacted, 0 when it never does
+func TokenBudget(apiName, model string) int {
+	info := AvailableAPIs[apiName]
+	if budget, ok := info.TokenBudgets[model]; ok {
+		return budget
+	}
+	return info.DefaultBudget
+}
@@ -1,34 +1,92 @@
 package api
 
-import "github.com/curator4/io-tui/types"
+import (
+	"context"
+	"fmt"
 
-// FunctionCall represents a function call from the AI
-type FunctionCall struct {
-    Name string
-    Args map[string]interface{}
-}
+	"github.com/curator4/io-tui/types"
+)
+
+// FunctionCall represents a function call from the AI, shared with
+// types.Message so calls can be replayed as conversation history
+type FunctionCall = types.FunctionCall
+
+// Usage is the token count reported for a request, zero when the provider
+// doesn't report it
+type Usage = types.Usage
 
 // ResponseWithFunctions represents a response that may contain both text and function calls
 type ResponseWithFunctions struct {
     Text          string
     FunctionCalls []FunctionCall
+    Usage         Usage
+}
+
+// StreamResult is sent once when a stream finishes successfully
+type StreamResult struct {
+	FunctionCalls []FunctionCall
+	Usage         Usage
+}
+
+// RequestConfig carries the active AI's settings for a single request,
+// so every call is made against the api/model stored on that AI
+type RequestConfig struct {
+	API          string
+	Model        string
+	SystemPrompt string
+	// Functions the model may call, none when empty
+	Functions []FunctionDeclaration
+	Params    Params
+}
+
+// ModelError is returned when the backend rejects the requested model
+type ModelError struct {
+	API   string
+	Model string
+	Err   error
 }
 
+func (e *ModelError) Error() string {
+	return fmt.Sprintf("model '%s' was rejected by %s: %v", e.Model, e.API, e.Err)
+}
+
+func (e *ModelError) Unwrap() error {
+	return e.Err
+}
+
+// Every request takes a context, cancelling it stops the generation.
+// Streaming providers close their channels once the context is done.
 type AIAPI interface {
-	GetResponse(messages []types.Message, systemPrompt string) (string, error)
+	GetResponse(ctx context.Context, messages []types.Message, config RequestConfig) (string, error)
 }
 
 type StreamingAPI interface {
 	AIAPI
-	GetStreamingResponse(messages []types.Message, systemPrompt string) (<-chan string, <-chan error)
+	GetStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan error)
 }
 
 type EnhancedStreamingAPI interface {
 	StreamingAPI
-	GetEnhancedStreamingResponse(messages []types.Message, systemPrompt string) (<-chan string, <-chan []FunctionCall, <-chan error)
+	GetEnhancedStreamingResponse(ctx context.Context, messages []types.Message, config RequestConfig) (<-chan string, <-chan StreamResult, <-chan error)
 }
 
 type FunctionAPI interface {
 	AIAPI
-	GetResponseWithFunctions(messages []types.Message, systemPrompt string) (*ResponseWithFunctions, error)
+	GetResponseWithFunctions(ctx context.Context, messages []types.Message, config RequestConfig) (*ResponseWithFunctions, error)
+}
+
+// ModelLister is implemented by providers that discover their models at runtime
+// instead of relying on the static APIInfo.Models list
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// sendText delivers a streamed chunk, giving up once the request is cancelled
+func sendText(ctx context.Context, textChan chan<- string, text string) bool {
+	select {
+	case textChan <- text:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
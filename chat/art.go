@@ -0,0 +1,129 @@
+package chat
+
+import (
+	"database/sql"
+	"fmt"
+	"image"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/visual"
+)
+
+const (
+	// scaled art is never shorter than this, smaller collapses like fixed art
+	minArtHeight = 8
+	maxArtHeight = 30
+	// renders kept before the cache starts over, resizing goes through many
+	artCacheSize = 16
+)
+
+// storeAIImage keeps the image an AI's art came from so it can be
+// re-rendered at other sizes. Without it the art still works, scaled from
+// the stored ascii.
+func storeAIImage(database *sql.DB, name string, image []byte) error {
+	if len(image) == 0 {
+		return nil
+	}
+	ai, err := db.GetAIByName(database, name)
+	if err != nil {
+		return fmt.Errorf("failed to store the image of %s: %w", name, err)
+	}
+	if err := db.SetAIImage(database, ai.ID, image); err != nil {
+		return fmt.Errorf("failed to store the image of %s: %w", name, err)
+	}
+	return nil
+}
+
+// imageWarning tells the user an AI was saved without its source image
+func imageWarning(err error) string {
+	return fmt.Sprintf("⚠️ %v, its art will scale from the ascii instead", err)
+}
+
+// artCache renders the active AI's art at the sizes the layout picks. The
+// source is the AI's stored image, or its ascii art for AIs saved before
+// images were kept.
+type artCache struct {
+	aiID  int
+	ascii string
+	// img is nil when the art can't scale, m.ascii is then used as is
+	img     image.Image
+	aspect  float64
+	renders map[[2]int]string
+}
+
+func newArtCache() *artCache {
+	return &artCache{}
+}
+
+// load switches to the AI's art, a no-op while it stays the same
+func (c *artCache) load(database *sql.DB, aiID int, ascii string) {
+	if c.renders != nil && c.aiID == aiID && c.ascii == ascii {
+		return
+	}
+	c.aiID, c.ascii = aiID, ascii
+	c.img, c.aspect = nil, 0
+	c.renders = map[[2]int]string{}
+
+	if data, err := db.GetAIImage(database, aiID); err == nil && data != nil {
+		if img, err := visual.DecodeImage(data); err == nil {
+			c.img = img
+		}
+	}
+	if c.img == nil {
+		if img, ok := visual.ImageFromASCII(ascii); ok {
+			c.img = img
+		}
+	}
+	if c.img != nil {
+		c.aspect = visual.ArtAspect(c.img)
+	}
+}
+
+// size is what computeLayout needs to know about the art
+func (c *artCache) size() artSize {
+	return artSize{
+		width:  lipgloss.Width(c.ascii),
+		height: lipgloss.Height(c.ascii),
+		aspect: c.aspect,
+	}
+}
+
+// render returns the art at width x height, the fixed art when it can't scale
+func (c *artCache) render(width, height int) string {
+	if c.img == nil {
+		return c.ascii
+	}
+	key := [2]int{width, height}
+	if art, ok := c.renders[key]; ok {
+		return art
+	}
+	if len(c.renders) >= artCacheSize {
+		c.renders = map[[2]int]string{}
+	}
+	art := visual.RenderASCII(c.img, width, height)
+	c.renders[key] = art
+	return art
+}
+
+// artSize is the art's fixed size, or its proportions when it scales
+type artSize struct {
+	width, height int
+	// aspect is columns per row, 0 for art that can't scale
+	aspect float64
+}
+
+// fit sizes the art within maxWidth x maxHeight, scaled art at up to
+// preferred rows. ok is false when it doesn't fit.
+func (a artSize) fit(maxWidth, maxHeight, preferred int) (width, height int, ok bool) {
+	if a.aspect == 0 {
+		return a.width, a.height, a.width <= maxWidth && a.height <= maxHeight
+	}
+	height = min(maxHeight, preferred, maxArtHeight)
+	width = int(float64(height)*a.aspect + 0.5)
+	if width > maxWidth {
+		width = maxWidth
+		height = int(float64(width) / a.aspect)
+	}
+	return width, height, height >= minArtHeight && width >= 1
+}
@@ -0,0 +1,187 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/types"
+)
+
+// branchState is the message tree of the active conversation. m.messages
+// shows one branch of it, new messages are stored as children of headID.
+type branchState struct {
+	conversationID int
+	// every stored row of the conversation, all branches, oldest first
+	tree []db.Message
+	// last stored row of the branch on screen, 0 before the first message
+	headID int
+	// where messages with alternatives sit among their siblings
+	positions map[int]branchPosition
+}
+
+type branchPosition struct {
+	index int
+	count int
+}
+
+func newBranchState(conversationID int, tree []db.Message, headID int) branchState {
+	b := branchState{conversationID: conversationID, tree: tree, headID: headID}
+	b.updatePositions()
+	return b
+}
+
+// updatePositions numbers the children of every parent with more than one
+func (b *branchState) updatePositions() {
+	children := make(map[int][]int)
+	for _, row := range b.tree {
+		children[row.ParentID] = append(children[row.ParentID], row.ID)
+	}
+	b.positions = make(map[int]branchPosition)
+	for _, ids := range children {
+		if len(ids) < 2 {
+			continue
+		}
+		for i, id := range ids {
+			b.positions[id] = branchPosition{index: i + 1, count: len(ids)}
+		}
+	}
+}
+
+// add records a newly stored row and makes it the head
+func (b *branchState) add(row db.Message) {
+	b.tree = append(b.tree, row)
+	b.headID = row.ID
+	if len(db.Siblings(b.tree, row.ID)) > 1 {
+		b.updatePositions()
+	}
+}
+
+// showBranch puts the branch ending at leafID on screen
+func (m *Model) showBranch(leafID int) {
+	m.messages = messagesFromDB(db.Branch(m.branch.tree, leafID))
+	m.branch.headID = leafID
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+}
+
+// switchBranch moves to the previous (-1) or next (+1) alternative at the
+// latest point on screen where the conversation branches
+func (m Model) switchBranch(delta int) (tea.Model, tea.Cmd) {
+	if m.generating() {
+		return m.showError("Wait for the response to finish (or stop it) before switching branches")
+	}
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		position, ok := m.branch.positions[m.messages[i].ID]
+		if !ok || m.messages[i].ID == 0 {
+			continue
+		}
+		siblings := db.Siblings(m.branch.tree, m.messages[i].ID)
+		next := siblings[(position.index-1+delta+len(siblings))%len(siblings)]
+
+		m.showBranch(db.LatestLeaf(m.branch.tree, next.ID))
+		m.scrollToMessage(next.ID)
+		return m, nil
+	}
+	return m.showError("No other branches here, start one with /fork")
+}
+
+// branchMarker shows which alternative a message is, e.g. "‹ 2/3 ›"
+func (m Model) branchMarker(msg types.Message) string {
+	position, ok := m.branch.positions[msg.ID]
+	if !ok || msg.ID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n‹ %d/%d ›", position.index, position.count)
+}
+
+// List item for picking the message to fork after
+type forkItem struct {
+	message types.Message
+}
+
+func (f forkItem) FilterValue() string { return f.message.Content }
+func (f forkItem) Title() string {
+	title := strings.Join(strings.Fields(f.message.Content), " ")
+	if len(title) > 80 {
+		title = title[:77] + "..."
+	}
+	return title
+}
+func (f forkItem) Description() string {
+	if f.message.Role == "user" {
+		return "you"
+	}
+	return "assistant"
+}
+
+// openForkSelector lists the messages of the branch on screen, newest first
+func (m Model) openForkSelector() (tea.Model, tea.Cmd) {
+	var items []list.Item
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		msg := m.messages[i]
+		// Forking between a function call and its result would leave the
+		// call unanswered
+		if msg.ID == 0 || (msg.Role != "user" && msg.Role != "assistant") || len(msg.FunctionCalls) > 0 {
+			continue
+		}
+		items = append(items, forkItem{message: msg})
+	}
+	if len(items) == 0 {
+		return m.showError("Nothing to fork yet, send a message first")
+	}
+
+	m.list.SetItems(items)
+	m.list.Title = "Fork after which message? (Enter to fork, Esc to cancel)"
+	m.list.SetShowStatusBar(false)
+	m.list.SetFilteringEnabled(true)
+	m.list.SetShowHelp(true)
+	m.viewMode = listMode
+	return m, nil
+}
+
+// forkAt cuts the conversation on screen back to messageID, the next
+// message starts a new branch from there. The old branch stays reachable.
+func (m Model) forkAt(messageID int) (tea.Model, tea.Cmd) {
+	if m.generating() {
+		m = m.interruptGeneration()
+	}
+	m.viewMode = chatMode
+	m.showBranch(messageID)
+	m.messages = append(m.messages, types.Message{
+		Role:    "system",
+		Content: fmt.Sprintf("🌱 Forked here, your next message starts a new branch (%s / %s switch branches)", m.keys.PrevBranch.Keys()[0], m.keys.NextBranch.Keys()[0]),
+	})
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// branchCommand switches branches without the keys: /branch prev|next
+func (m Model) branchCommand(args []string) (tea.Model, tea.Cmd) {
+	if len(args) != 1 {
+		return m.showError("Usage: /branch prev|next")
+	}
+	switch args[0] {
+	case "prev":
+		return m.switchBranch(-1)
+	case "next":
+		return m.switchBranch(1)
+	default:
+		return m.showError("Usage: /branch prev|next")
+	}
+}
+
+// storedRow is the tree entry for a row just saved
+func storedRow(id int, row db.Message) db.Message {
+	row.ID = id
+	row.Created = time.Now().UTC().Format(db.TimestampLayout)
+	return row
+}
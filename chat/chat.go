@@ -4,12 +4,15 @@ package chat
 // component library.
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"os"
 	"time"
 	"database/sql"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
@@ -19,13 +22,13 @@ import (
 
 	"github.com/curator4/io-tui/ai"
 	"github.com/curator4/io-tui/api"
+	"github.com/curator4/io-tui/config"
 	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/tools"
 	"github.com/curator4/io-tui/types"
 	"github.com/curator4/io-tui/visual"
 )
 
-const gap = "\n\n\n"
-
 
 // updateModelPalette parses the AI's palette JSON and updates the model
 func updateModelPalette(m *Model) {
@@ -35,11 +38,8 @@ func updateModelPalette(m *Model) {
 			return
 		}
 	}
-	// Fallback palette if AI has no palette
-	m.palette = []string{
-		"#0061cd", "#ff79c6", "#1e40af", "#60a5fa", 
-		"#fbbf24", "#e5e7eb", "#22d3ee", "#950056",
-	}
+	// Fallback palette (from the config) if AI has no palette
+	m.palette = m.config.UI.Palette
 }
 
 type (
@@ -54,7 +54,8 @@ const (
 
 
 type AIResponseMsg struct {
-	message types.Message
+	generation int
+	message    types.Message
 }
 
 type AIIntroductionMsg struct {
@@ -66,15 +67,18 @@ type APIStatusMsg struct {
 }
 
 type AIErrorMsg struct {
-	message types.Message
+	generation int
+	message    types.Message
 }
 
 type AIStreamStartMsg struct {
-	textChan <-chan string
-	errChan  <-chan error
+	generation int
+	textChan   <-chan string
+	errChan    <-chan error
 }
 
 type AIStreamChunkMsg struct {
+	generation    int
 	chunk         string
 	functionCalls []api.FunctionCall
 	textChan      <-chan string
@@ -82,30 +86,35 @@ type AIStreamChunkMsg struct {
 }
 
 type AIStreamCompleteMsg struct {
+	generation int
+	usage      api.Usage
 }
 
 type AIEnhancedStreamStartMsg struct {
-	textChan <-chan string
-	funcChan <-chan []api.FunctionCall
-	errChan  <-chan error
+	generation int
+	textChan   <-chan string
+	resultChan <-chan api.StreamResult
+	errChan    <-chan error
 }
 
 type AIEnhancedStreamChunkMsg struct {
-	chunk    string
-	textChan <-chan string
-	funcChan <-chan []api.FunctionCall
-	errChan  <-chan error
+	generation int
+	chunk      string
+	textChan   <-chan string
+	resultChan <-chan api.StreamResult
+	errChan    <-chan error
 }
 
 type AIEnhancedStreamFunctionMsg struct {
+	generation    int
 	functionCalls []api.FunctionCall
-	textChan      <-chan string
-	funcChan      <-chan []api.FunctionCall
-	errChan       <-chan error
+	usage         api.Usage
 }
 
 type ManifestSuccessMsg struct {
 	aiName string
+	// warning is shown once switched, the AI was saved regardless
+	warning string
 }
 
 type ManifestErrorMsg struct {
@@ -113,8 +122,16 @@ type ManifestErrorMsg struct {
 }
 
 type AIFunctionCallMsg struct {
+	generation    int
 	text          string
 	functionCalls []api.FunctionCall
+	usage         api.Usage
+}
+
+type FunctionResultsMsg struct {
+	generation int
+	results    []types.Message
+	switchAI   string
 }
 
 // api state
@@ -141,6 +158,8 @@ const (
 	Processing
 	Typing
 	Manifesting
+	UsingTools
+	Compacting
 	Error
 )
 
@@ -148,16 +167,18 @@ type statusPanel struct {
 	spinner spinner.Model
 	status statusState
 	manifestingName string
+	// what the running tools are doing, shown while UsingTools
+	activity string
 }
 
 type Model struct {
 	// database reference
 	database *sql.DB
+	// settings from config.toml and the key bindings built from them
+	config config.Config
+	keys   keyMap
 
-	// a struct that has an API interface (handles requests).
-	// convoluted way to set it up, i know...
-	// but i was considering further stuff like api indepedent tools
-	// actually, thinking again, i dont think this was necisarry
+	// resolves the provider (handles requests) for the active ai's api.
 	// i used "core" cuz i dont like the term "manager"
 	aicore ai.Core
 
@@ -179,11 +200,41 @@ type Model struct {
 	height      int
 	statusPanel	statusPanel
 	apiStatus   apiState
+	// context of the in-flight request (kept across function call rounds)
+	// and its cancel func, both nil when nothing is generating
+	generationCtx    context.Context
+	cancelGeneration context.CancelFunc
+	// generation numbers every request, messages of older ones are dropped
+	generation int
+	// function call rounds used by the current generation
+	toolRounds int
+	// ai a tool asked to switch to once the reply is done
+	pendingSwitch string
+	// message tree of the conversation and the branch being continued
+	branch branchState
+	// user message being edited with /edit, 0 when not editing
+	editingID int
+	// a /compact summary is being written
+	compacting bool
+	// where the panels and chat go, recomputed after every update
+	layout layout
+	// layoutMode is auto, stacked or side, panels are the ones shown
+	layoutMode string
+	panels     panels
+	// message cursor for copying, see selection.go
+	selection selection
+	// rendered assistant markdown, shared by every copy of the model
+	markdown *markdownCache
+	// the AI's art rendered at layout sizes, see art.go
+	art *artCache
+	// tokens and estimated cost of every response since startup
+	sessionUsage types.Usage
+	sessionCost  float64
 	viewMode    viewMode
 	err         error
 }
 
-func InitialModel(database *sql.DB) Model {
+func InitialModel(database *sql.DB, cfg config.Config) Model {
 
 
 
@@ -195,7 +246,7 @@ func InitialModel(database *sql.DB) Model {
 	ta.CharLimit = 2000
 
 	ta.SetWidth(30)
-	ta.SetHeight(2)
+	ta.SetHeight(cfg.UI.InputHeight)
 
 	// Remove cursor line styling
 	ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
@@ -215,7 +266,7 @@ func InitialModel(database *sql.DB) Model {
 	
 	activeAI, err := db.GetActiveAI(database)
 	if err != nil {
-		fmt.Printf("no initial ai %w", err)
+		fmt.Printf("no initial ai %v", err)
 		os.Exit(1)
 	}
 	// Load ASCII art from database with ANSI conversion
@@ -229,9 +280,15 @@ func InitialModel(database *sql.DB) Model {
 
 	m := Model{
 		database:	 database,
+		config:      cfg,
+		keys:        newKeyMap(cfg.Keys),
+		markdown:    newMarkdownCache(),
+		art:         newArtCache(),
+		layoutMode:  cfg.UI.Layout,
+		panels:      panels{art: cfg.UI.ShowHeader, info: cfg.UI.ShowHeader},
 		ai:			 activeAI,
 		conversation: db.Conversation{}, // Empty struct instead of nil
-		aicore:		 ai.NewCore(),
+		aicore:		 ai.NewCore(cfg.Providers),
 		viewport:    vp,
 		textarea:    ta,
 		list:        list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0),
@@ -246,6 +303,10 @@ func InitialModel(database *sql.DB) Model {
 	
 	// Parse and set palette from AI
 	updateModelPalette(&m)
+
+	// Resolve the active provider before the TUI takes the screen, so a key
+	// command that prompts (pass, pinentry) can. Errors show on first use.
+	m.aicore.Provider(activeAI.API)
 	
 	return m
 }
@@ -254,7 +315,18 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(textarea.Blink, m.statusPanel.spinner.Tick)
 }
 
+// Update handles a message and then fits the layout to the terminal, which
+// also catches changes that resize panels (another AI's art, /layout)
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	if next, ok := updated.(Model); ok {
+		next.applyLayout()
+		return next, cmd
+	}
+	return updated, cmd
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
 		tiCmd tea.Cmd
 		vpCmd tea.Cmd
@@ -262,21 +334,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case AIErrorMsg:
+		// Ignore results of a generation the user already stopped or replaced
+		if !m.current(msg.generation) {
+			return m, nil
+		}
+		afterCmd := m.completeGeneration()
 		// API error - keep status offline and don't save to database
 		m.apiStatus = offline
+		// Drop the empty placeholder left behind by a failed stream
+		if len(m.messages) > 0 && m.messages[len(m.messages)-1].Role == "assistant" && m.messages[len(m.messages)-1].Content == "" {
+			m.messages = m.messages[:len(m.messages)-1]
+		}
 		m.messages = append(m.messages, msg.message)
 		m.statusPanel.status = AtEase
 		if m.viewport.Height > 0 {
 			m.viewport.SetContent(m.formatMessages())
 			m.viewport.GotoBottom()
 		}
+		if afterCmd != nil {
+			return m, afterCmd
+		}
 		
 	case AIResponseMsg:
+		if !m.current(msg.generation) {
+			return m, nil
+		}
+		afterCmd := m.completeGeneration()
 		// Successful API response - set status to online
 		m.apiStatus = online
 		
 		// Save to database and add to display cache
-		if err := db.SaveMessage(m.database, m.conversation.ID, "assistant", msg.message.Content); err != nil {
+		m.recordUsage(msg.message.Usage)
+		m.messages = append(m.messages, msg.message)
+		if err := m.saveLastMessage(); err != nil {
 			// Add error message to chat if save fails
 			errorMsg := types.Message{
 				Role:    "system",
@@ -284,55 +374,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.messages = append(m.messages, errorMsg)
 		}
-		m.messages = append(m.messages, msg.message)
 		m.statusPanel.status = AtEase
 		if m.viewport.Height > 0 {
 			m.viewport.SetContent(m.formatMessages())
 			m.viewport.GotoBottom()
 		}
-		return m, nil
+		return m, afterCmd
 
 	case AIFunctionCallMsg:
-		// Execute function calls silently (no text response)
-		for _, funcCall := range msg.functionCalls {
-			if funcCall.Name == "manifest_character" {
-				// Extract arguments
-				name, nameOk := funcCall.Args["name"].(string)
-				imageURL, imageOk := funcCall.Args["image_url"].(string)
-				description, descOk := funcCall.Args["description"].(string)
-				
-				if !nameOk || !imageOk || !descOk {
-					errorMsg := types.Message{
-						Role:    "system",
-						Content: "🔥 Manifest failed: Missing required parameters",
-					}
-					m.messages = append(m.messages, errorMsg)
-					continue
-				}
-				
-				
-				// Set manifesting status before calling the function
-				m.statusPanel.status = Manifesting
-				m.statusPanel.manifestingName = name
-				
-				// Update display to show manifesting status
-				if m.viewport.Height > 0 {
-					m.viewport.SetContent(m.formatMessages())
-					m.viewport.GotoBottom()
-				}
-				
-				// Use existing manifest infrastructure but with description for system prompt
-				return m, m.processManifestWithDescription(name, imageURL, description)
-			}
+		if !m.current(msg.generation) {
+			return m, nil
 		}
-		
-		// Update display if no function calls were handled
-		if m.viewport.Height > 0 {
-			m.viewport.SetContent(m.formatMessages())
-			m.viewport.GotoBottom()
+		m.apiStatus = online
+		// Record the assistant turn that asked for the calls, then run them
+		m.messages = append(m.messages, types.Message{Role: "assistant", Content: msg.text})
+		return m.runFunctionCalls(msg.functionCalls, msg.usage)
+
+	case FunctionResultsMsg:
+		if !m.current(msg.generation) {
+			return m, nil
 		}
-		m.statusPanel.status = AtEase
-		return m, nil
+		return m.continueWithResults(msg)
 
 	case AIIntroductionMsg:
 		// Add to display without saving to database
@@ -345,6 +407,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case AIStreamStartMsg:
+		if !m.current(msg.generation) {
+			return m, nil
+		}
 		// Add empty bot message immediately
 		m.messages = append(m.messages, types.Message{Role: "assistant", Content: ""})
 		m.statusPanel.status = Processing
@@ -356,6 +421,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.readNextChunk(msg.textChan, msg.errChan)
 
 	case AIStreamChunkMsg:
+		if !m.current(msg.generation) {
+			return m, nil
+		}
 		// Append chunk to last bot message
 		if len(m.messages) > 0 && m.messages[len(m.messages)-1].Role == "assistant" {
 			m.messages[len(m.messages)-1].Content += msg.chunk
@@ -368,10 +436,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.readNextChunk(msg.textChan, msg.errChan)
 
 	case AIStreamCompleteMsg:
+		if !m.current(msg.generation) {
+			return m, nil
+		}
+		afterCmd := m.completeGeneration()
 		// Save the complete streamed message to database
 		if len(m.messages) > 0 && m.messages[len(m.messages)-1].Role == "assistant" {
-			lastMessage := m.messages[len(m.messages)-1]
-			if err := db.SaveMessage(m.database, m.conversation.ID, "assistant", lastMessage.Content); err != nil {
+			m.messages[len(m.messages)-1].Usage = msg.usage
+			m.recordUsage(msg.usage)
+			if err := m.saveLastMessage(); err != nil {
 				// Add error message to chat if save fails
 				errorMsg := types.Message{
 					Role:    "system",
@@ -385,9 +458,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.statusPanel.status = AtEase
-		return m, nil
+		return m, afterCmd
 
 	case AIEnhancedStreamStartMsg:
+		if !m.current(msg.generation) {
+			return m, nil
+		}
 		// Successful streaming start - set API status to online
 		m.apiStatus = online
 		// Add empty bot message immediately
@@ -398,9 +474,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.GotoBottom()
 		}
 		// Start reading first chunk or function call
-		return m, m.readNextEnhancedChunk(msg.textChan, msg.funcChan, msg.errChan)
+		return m, m.readNextEnhancedChunk(msg.textChan, msg.resultChan, msg.errChan)
 
 	case AIEnhancedStreamChunkMsg:
+		if !m.current(msg.generation) {
+			return m, nil
+		}
 		// Append chunk to last bot message
 		if len(m.messages) > 0 && m.messages[len(m.messages)-1].Role == "assistant" {
 			m.messages[len(m.messages)-1].Content += msg.chunk
@@ -410,47 +489,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.SetContent(m.formatMessages())
 			m.viewport.GotoBottom()
 		}
-		return m, m.readNextEnhancedChunk(msg.textChan, msg.funcChan, msg.errChan)
+		return m, m.readNextEnhancedChunk(msg.textChan, msg.resultChan, msg.errChan)
 
 	case AIEnhancedStreamFunctionMsg:
-		// Handle function calls - for manifest, stop streaming and just do the function
-		for _, funcCall := range msg.functionCalls {
-			if funcCall.Name == "manifest_character" {
-				// Extract arguments
-				name, nameOk := funcCall.Args["name"].(string)
-				imageURL, imageOk := funcCall.Args["image_url"].(string)
-				description, descOk := funcCall.Args["description"].(string)
-				
-				if !nameOk || !imageOk || !descOk {
-					errorMsg := types.Message{
-						Role:    "system",
-						Content: "🔥 Manifest failed: Missing required parameters",
-					}
-					m.messages = append(m.messages, errorMsg)
-					m.statusPanel.status = AtEase
-					if m.viewport.Height > 0 {
-						m.viewport.SetContent(m.formatMessages())
-						m.viewport.GotoBottom()
-					}
-					return m, nil
-				}
-				
-				// Set manifesting status
-				m.statusPanel.status = Manifesting
-				m.statusPanel.manifestingName = name
-				
-				// Update display to show manifesting status
-				if m.viewport.Height > 0 {
-					m.viewport.SetContent(m.formatMessages())
-					m.viewport.GotoBottom()
-				}
-				
-				// For manifest, stop streaming and just execute the function
-				return m, m.processManifestWithDescription(name, imageURL, description)
-			}
+		if !m.current(msg.generation) {
+			return m, nil
 		}
-		// Continue reading the stream for other function calls
-		return m, m.readNextEnhancedChunk(msg.textChan, msg.funcChan, msg.errChan)
+		// The streamed text (possibly empty) is the turn that asked for the calls
+		return m.runFunctionCalls(msg.functionCalls, msg.usage)
 
 	case ManifestSuccessMsg:
 		// Automatically switch to the newly created AI
@@ -470,6 +516,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Clear conversation since we switched AIs
 			m.conversation = db.Conversation{}
 			m.messages = []types.Message{}
+			if msg.warning != "" {
+				m.messages = append(m.messages, types.Message{Role: "system", Content: msg.warning})
+			}
 			
 			// Update viewport to clear display
 			if m.viewport.Height > 0 {
@@ -483,6 +532,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Get AI introduction after switching
 		return m, m.getAIIntroduction()
 
+	case compactCheckMsg:
+		return m.autoCompact()
+
+	case apiCheckedMsg:
+		return m.switchAPI(msg)
+
+	case modelsMsg:
+		return m.modelsLoaded(msg)
+
+	case CompactDoneMsg:
+		return m.finishCompaction(msg)
+
+	case ImportDoneMsg:
+		m.messages = append(m.messages, msg.message)
+		if m.viewport.Height > 0 {
+			m.viewport.SetContent(m.formatMessages())
+			m.viewport.GotoBottom()
+		}
+		return m, nil
+		
 	case ManifestErrorMsg:
 		m.messages = append(m.messages, msg.message)
 		m.statusPanel.status = AtEase
@@ -493,28 +562,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 		
 	case tea.WindowSizeMsg:
+		// Update lays everything out again for the new size
 		m.width = msg.Width
 		m.height = msg.Height
 		
-		asciiHeight := lipgloss.Height(m.ascii)
-		// Account for border width in component sizing
-		borderWidth := 2
-		m.viewport.Width = msg.Width - borderWidth
-		m.textarea.SetWidth(msg.Width - borderWidth)
-		
-		// Calculate height with minimum safety check
-		newHeight := msg.Height - m.textarea.Height() - lipgloss.Height(gap) - asciiHeight
-		if newHeight < 1 {
-			newHeight = 1  // Minimum height of 1
-		}
-		m.viewport.Height = newHeight
-		
-		// Only update content and scroll if we have valid dimensions
-		if len(m.messages) > 0 && m.viewport.Width > 0 && m.viewport.Height > 0 {
-			m.viewport.SetContent(m.formatMessages())
-			m.viewport.GotoBottom()
-		}
-		
 	case tea.KeyMsg:
 		// Handle list mode separately
 		if m.viewMode == listMode {
@@ -577,6 +628,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						// Resume this conversation
 						return m.resumeConversation(conversationItem.conversation.ID)
 					}
+					if forkItem, ok := selectedItem.(forkItem); ok {
+						// Continue from this message on a new branch
+						return m.forkAt(forkItem.message.ID)
+					}
+					if searchItem, ok := selectedItem.(searchItem); ok {
+						// Resume the conversation at the matched message
+						return m.openSearchHit(searchItem.hit)
+					}
 				}
 			default:
 				// Let list handle navigation
@@ -587,17 +646,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		
-		// Chat mode key handling
-		switch msg.Type {
-		case tea.KeyUp, tea.KeyDown:
+		// Selection mode takes every key but quit
+		if m.selection.active && !key.Matches(msg, m.keys.Quit) {
+			return m.handleSelectionKey(msg)
+		}
+
+		// Chat mode key handling, send/stop/quit are configurable
+		switch {
+		case msg.Type == tea.KeyUp || msg.Type == tea.KeyDown:
 			// Arrow keys only go to textarea for navigation
 			m.textarea, tiCmd = m.textarea.Update(msg)
 
-		case tea.KeyCtrlC, tea.KeyEsc:
+		case key.Matches(msg, m.keys.Stop):
+			// Esc stops a running generation or leaves edit mode before it quits
+			if m.generating() {
+				m = m.interruptGeneration()
+				return m, nil
+			}
+			if m.editingID != 0 {
+				return m.cancelEdit()
+			}
+			fmt.Println(m.textarea.Value())
+			return m, tea.Quit
+
+		case key.Matches(msg, m.keys.Select):
+			return m.startSelection()
+
+		case key.Matches(msg, m.keys.PrevBranch):
+			return m.switchBranch(-1)
+
+		case key.Matches(msg, m.keys.NextBranch):
+			return m.switchBranch(1)
+
+		case key.Matches(msg, m.keys.Quit):
+			m.finishGeneration()
 			fmt.Println(m.textarea.Value())
 			return m, tea.Quit
 
-		case tea.KeyEnter:
+		case key.Matches(msg, m.keys.Send):
 			userInput := m.textarea.Value()
 			
 			// Don't send empty messages
@@ -605,11 +691,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+			// #text is remembered by the active AI instead of sent
+			if strings.HasPrefix(userInput, "#") {
+				return m.rememberInput(userInput)
+			}
+
 			// Handle slash commands and vim-style quit
 			if strings.HasPrefix(userInput, "/") || userInput == ":q" {
 				return m.handleSlashCommand(userInput)
 			}
 
+			// Sending while a response streams in interrupts it
+			if m.generating() {
+				m = m.interruptGeneration()
+			}
+
+			// An edit replaces the message it was started from
+			if m.editingID != 0 {
+				m.applyEdit()
+			}
+
 			// create conversation if none is active
 			if m.conversation.ID == 0 {
 				conv, _ := db.CreateConversation(m.database, userInput, m.ai.ID)
@@ -620,8 +721,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				Content: userInput,
 				Role: "user",
 			}
+			m.messages = append(m.messages, userMessage)
 			// Save to database
-			if err := db.SaveMessage(m.database, m.conversation.ID, "user", userInput); err != nil {
+			if err := m.saveLastMessage(); err != nil {
 				// Add error message to chat if save fails
 				errorMsg := types.Message{
 					Role:    "system",
@@ -629,7 +731,6 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.messages = append(m.messages, errorMsg)
 			}
-			m.messages = append(m.messages, userMessage)
 			m.statusPanel.status = Processing
 
 			// Update viewport content safely
@@ -639,7 +740,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.textarea.Reset()
 
-			return m, m.callAI(userInput)
+			ctx := m.startGeneration()
+			return m, m.callAI(ctx, userInput)
 
 		default:
 			// All other keys go to textarea
@@ -683,37 +785,6 @@ func (m Model) View() string {
 		BorderStyle(lipgloss.RoundedBorder()).
 		BorderForeground(lipgloss.Color(m.palette[3]))
 
-	// Account for border width (2 chars: left + right border)
-	borderWidth := 2
-	contentWidth := m.width - borderWidth
-	
-	// Calculate heights for right panel components
-	statusPanelHeight := 3
-	separatorHeight := 1
-	asciiHeight := lipgloss.Height(m.ascii)
-	infoPanelHeight := asciiHeight - statusPanelHeight - separatorHeight
-	
-	// Create info panel with calculated height
-	infoPanelStyle := lipgloss.NewStyle().Height(infoPanelHeight)
-	infoContent := m.makeInfoPanel()
-	styledInfoPanel := infoPanelStyle.Render(infoContent)
-	
-	// right panel
-	rightPanel := lipgloss.JoinVertical(
-		lipgloss.Center,
-		styledInfoPanel,
-		m.horizontalSeparator(contentWidth - lipgloss.Width(m.ascii) - 2),
-		m.makeStatusPanel(),
-	)
-	
-	// top panel
-	topPanel := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		m.ascii,
-		m.verticalSeparator(lipgloss.Height(m.ascii)),
-		rightPanel,
-	)
-	
 	// Conditional main content based on view mode
 	var mainContent string
 	if m.viewMode == listMode {
@@ -728,19 +799,52 @@ func (m Model) View() string {
 		// Normal chat viewport
 		mainContent = m.viewport.View()
 	}
-	
-	content := lipgloss.JoinVertical(
-		lipgloss.Left,
-		topPanel,
-		m.horizontalSeparator(contentWidth),
+
+	chat := lipgloss.JoinVertical(lipgloss.Left,
 		mainContent,
-		m.horizontalSeparator(contentWidth),
+		m.horizontalSeparator(m.layout.chatWidth),
 		m.textarea.View(),
 	)
+
+	// Panels go beside or above the chat, see layout.go
+	var content string
+	switch {
+	case m.layout.side:
+		content = lipgloss.JoinHorizontal(lipgloss.Top,
+			chat,
+			m.verticalSeparator(m.layout.height),
+			m.sideColumn(),
+		)
+	case m.layout.headerHeight > 0:
+		content = lipgloss.JoinVertical(lipgloss.Left,
+			m.header(),
+			m.horizontalSeparator(m.layout.width),
+			chat,
+		)
+	default:
+		content = chat
+	}
 	return contentBorder.Render(content)
 }
 
+// formatMessages renders the whole transcript, then drops cached markdown
+// it no longer shows
 func (m Model) formatMessages() string {
+	defer m.markdown.prune()
+	return m.renderMessages()
+}
+
+// messagesHeight is how many lines the first n messages take, for scroll
+// offsets. The cache isn't pruned since only part of the transcript is drawn.
+func (m Model) messagesHeight(n int) int {
+	if n == 0 {
+		return 0
+	}
+	m.messages = m.messages[:n]
+	return lipgloss.Height(m.renderMessages())
+}
+
+func (m Model) renderMessages() string {
 	userStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color(m.palette[0])).
 		Align(lipgloss.Right).
@@ -750,6 +854,9 @@ func (m Model) formatMessages() string {
 		Align(lipgloss.Left).
 		Width(m.viewport.Width)
 
+	// Styles follow the palette and wrap to the viewport
+	m.markdown.prepare(m.palette, m.viewport.Width)
+
 	var content strings.Builder
 	var lastRole string
 
@@ -781,12 +888,42 @@ func (m Model) formatMessages() string {
 			content.WriteString(separator + "\n")
 		}
 
+		align := lipgloss.Left
+		if msg.Role == "user" {
+			align = lipgloss.Right
+		}
+		content.WriteString(m.selectionMarker(i, align))
+
 		var styledMessage string
 		switch msg.Role {
 		case "user":
-			styledMessage = userStyle.Render(msg.Content)
+			styledMessage = userStyle.Render(msg.Content + m.branchMarker(msg))
 		case "assistant":
-			styledMessage = botStyle.Render(msg.Content)
+			streaming := i == len(m.messages)-1 && m.generating()
+			var parts []string
+			if msg.Content != "" {
+				parts = append(parts, m.markdown.render(msg.Content, streaming, botStyle))
+			}
+			var calls []string
+			for _, call := range msg.FunctionCalls {
+				calls = append(calls, "🔧 "+call.Name)
+			}
+			if extra := strings.Join(calls, "\n") + m.branchMarker(msg); extra != "" {
+				parts = append(parts, botStyle.Render(strings.TrimPrefix(extra, "\n")))
+			}
+			styledMessage = strings.Join(parts, "\n")
+		case "tool":
+			toolStyle := lipgloss.NewStyle().
+				Foreground(lipgloss.Color(m.palette[4])).
+				Align(lipgloss.Left).
+				Width(m.viewport.Width)
+			styledMessage = toolStyle.Render(fmt.Sprintf("↳ %s: %s", msg.FunctionName, msg.Content))
+		case summaryRole:
+			summaryStyle := lipgloss.NewStyle().
+				Foreground(lipgloss.Color(m.palette[4])).
+				Align(lipgloss.Left).
+				Width(m.viewport.Width)
+			styledMessage = "\n" + summaryStyle.Render("📝 Earlier messages, summarized:\n"+msg.Content) + "\n"
 		case "system":
 			systemStyle := lipgloss.NewStyle().
 				Foreground(lipgloss.Color(m.palette[4])).
@@ -800,40 +937,29 @@ func (m Model) formatMessages() string {
 	return content.String()
 }
 
-func (m Model) getAIResponse() tea.Cmd {
+func (m Model) getAIResponse(ctx context.Context, provider api.AIAPI) tea.Cmd {
 	return func() tea.Msg {
-		// Filter out system messages for API calls
-		var apiMessages []types.Message
-		for _, msg := range m.messages {
-			if msg.Role != "system" {
-				apiMessages = append(apiMessages, msg)
-			}
-		}
+		// Summarized turns go in the system prompt, the rest as messages
+		_, apiMessages := m.contextMessages()
 		
 		// Check if API supports function calling  
-		if functionAPI, ok := m.aicore.API.(api.FunctionAPI); ok {
+		if functionAPI, ok := provider.(api.FunctionAPI); ok {
 			// Use function calling version
-			response, err := functionAPI.GetResponseWithFunctions(apiMessages, m.ai.SystemPrompt)
+			response, err := functionAPI.GetResponseWithFunctions(ctx, apiMessages, m.chatRequestConfig())
 			if err != nil {
-				// Clean up error message
-				errorContent := "❌ No API key configured. Please set GEMINI_API_KEY or GOOGLE_API_KEY, or update demo_api_key.txt"
-				if !strings.Contains(err.Error(), "API key") && 
-				   !strings.Contains(err.Error(), "Demo_Key_Replace") && 
-				   !strings.Contains(err.Error(), "invalid header field value") {
-					errorContent = fmt.Sprintf("❌ API Error: %v", err)
-				}
-				
 				return AIErrorMsg{
-					message: types.Message{
+					generation: m.generation,
+					message:    types.Message{
 						Role:    "assistant",
-						Content: errorContent,
+						Content: formatAPIError(err),
 					},
 				}
 			}
 			
 			if response == nil {
 				return AIResponseMsg{
-					message: types.Message{
+					generation: m.generation,
+					message:    types.Message{
 						Role:    "assistant",
 						Content: "No response received",
 					},
@@ -843,40 +969,38 @@ func (m Model) getAIResponse() tea.Cmd {
 			// Handle function calls
 			if len(response.FunctionCalls) > 0 {
 				return AIFunctionCallMsg{
+					generation:    m.generation,
 					text:          response.Text,
 					functionCalls: response.FunctionCalls,
+					usage:         response.Usage,
 				}
 			}
 			
 			
 			return AIResponseMsg{
-				message: types.Message{
+				generation: m.generation,
+				message:    types.Message{
 					Role:    "assistant",
 					Content: response.Text,
+					Usage:   response.Usage,
 				},
 			}
 		}
 		
 		// Fallback for non-function APIs
-		response, err := m.aicore.API.GetResponse(apiMessages, m.ai.SystemPrompt)
+		response, err := provider.GetResponse(ctx, apiMessages, m.chatRequestConfig())
 		if err != nil {
-			// Clean up error message
-			errorContent := "❌ No API key configured. Please set GEMINI_API_KEY or GOOGLE_API_KEY, or update demo_api_key.txt"
-			if !strings.Contains(err.Error(), "API key") && 
-			   !strings.Contains(err.Error(), "Demo_Key_Replace") && 
-			   !strings.Contains(err.Error(), "invalid header field value") {
-				errorContent = fmt.Sprintf("❌ API Error: %v", err)
-			}
-			
 			return AIErrorMsg{
-				message: types.Message{
+				generation: m.generation,
+				message:    types.Message{
 					Role:    "assistant",
-					Content: errorContent,
+					Content: formatAPIError(err),
 				},
 			}
 		}
 		return AIResponseMsg{
-			message: types.Message{
+			generation: m.generation,
+			message:    types.Message{
 				Role:    "assistant",
 				Content: response,
 			},
@@ -884,143 +1008,107 @@ func (m Model) getAIResponse() tea.Cmd {
 	}
 }
 
-func (m Model) getAIStreamingResponse(streamingAPI api.StreamingAPI) tea.Cmd {
+func (m Model) getAIStreamingResponse(ctx context.Context, streamingAPI api.StreamingAPI) tea.Cmd {
 	return func() tea.Msg {
-		// Filter out system messages for API calls
-		var apiMessages []types.Message
-		for _, msg := range m.messages {
-			if msg.Role != "system" {
-				apiMessages = append(apiMessages, msg)
-			}
-		}
+		// Summarized turns go in the system prompt, the rest as messages
+		_, apiMessages := m.contextMessages()
 		
 		// Start streaming
-		textChan, errChan := streamingAPI.GetStreamingResponse(apiMessages, m.ai.SystemPrompt)
+		textChan, errChan := streamingAPI.GetStreamingResponse(ctx, apiMessages, m.chatRequestConfig())
 		
 		return AIStreamStartMsg{
-			textChan: textChan,
-			errChan:  errChan,
+			generation: m.generation,
+			textChan:   textChan,
+			errChan:    errChan,
 		}
 	}
 }
 
 func (m Model) readNextChunk(textChan <-chan string, errChan <-chan error) tea.Cmd {
 	return func() tea.Msg {
-		select {
-		case chunk, ok := <-textChan:
-			if !ok {
-				return AIStreamCompleteMsg{}
-			}
-			return AIStreamChunkMsg{
-				chunk:    chunk,
-				textChan: textChan,
-				errChan:  errChan,
-			}
-		case err := <-errChan:
-			if err != nil {
-				return AIResponseMsg{
-					message: types.Message{Role: "assistant", Content: fmt.Sprintf("Error: %v", err)},
+		chunk, ok := <-textChan
+		if !ok {
+			// Stream finished, errors are buffered before textChan closes
+			if err := <-errChan; err != nil {
+				return AIErrorMsg{
+					generation: m.generation,
+					message:    types.Message{Role: "assistant", Content: formatAPIError(err)},
 				}
 			}
-			return AIStreamCompleteMsg{}
+			return AIStreamCompleteMsg{generation: m.generation}
+		}
+		return AIStreamChunkMsg{
+			generation: m.generation,
+			chunk:      chunk,
+			textChan:   textChan,
+			errChan:    errChan,
 		}
 	}
 }
 
-func (m Model) getEnhancedStreamingResponse(enhancedAPI api.EnhancedStreamingAPI) tea.Cmd {
+func (m Model) getEnhancedStreamingResponse(ctx context.Context, enhancedAPI api.EnhancedStreamingAPI) tea.Cmd {
 	return func() tea.Msg {
-		// Filter out system messages for API calls
-		var apiMessages []types.Message
-		for _, msg := range m.messages {
-			if msg.Role != "system" {
-				apiMessages = append(apiMessages, msg)
-			}
-		}
+		// Summarized turns go in the system prompt, the rest as messages
+		_, apiMessages := m.contextMessages()
 		
 		// Start enhanced streaming
-		textChan, funcChan, errChan := enhancedAPI.GetEnhancedStreamingResponse(apiMessages, m.ai.SystemPrompt)
+		textChan, resultChan, errChan := enhancedAPI.GetEnhancedStreamingResponse(ctx, apiMessages, m.chatRequestConfig())
 		
 		return AIEnhancedStreamStartMsg{
-			textChan: textChan,
-			funcChan: funcChan,
-			errChan:  errChan,
+			generation: m.generation,
+			textChan:   textChan,
+			resultChan: resultChan,
+			errChan:    errChan,
 		}
 	}
 }
 
-func (m Model) readNextEnhancedChunk(textChan <-chan string, funcChan <-chan []api.FunctionCall, errChan <-chan error) tea.Cmd {
+func (m Model) readNextEnhancedChunk(textChan <-chan string, resultChan <-chan api.StreamResult, errChan <-chan error) tea.Cmd {
 	return func() tea.Msg {
-		select {
-		case chunk, ok := <-textChan:
-			if !ok {
-				// Text channel closed, check if function channel has data
-				select {
-				case funcs, funcOk := <-funcChan:
-					if funcOk && len(funcs) > 0 {
-						return AIEnhancedStreamFunctionMsg{
-							functionCalls: funcs,
-							textChan:      textChan,
-							funcChan:      funcChan,
-							errChan:       errChan,
-						}
-					}
-				default:
-				}
-				return AIStreamCompleteMsg{}
-			}
+		chunk, ok := <-textChan
+		if ok {
 			return AIEnhancedStreamChunkMsg{
-				chunk:    chunk,
-				textChan: textChan,
-				funcChan: funcChan,
-				errChan:  errChan,
+				generation: m.generation,
+				chunk:      chunk,
+				textChan:   textChan,
+				resultChan: resultChan,
+				errChan:    errChan,
 			}
-		case funcs, ok := <-funcChan:
-			if ok && len(funcs) > 0 {
-				return AIEnhancedStreamFunctionMsg{
-					functionCalls: funcs,
-					textChan:      textChan,
-					funcChan:      funcChan,
-					errChan:       errChan,
-				}
+		}
+
+		// Text channel closed - errors and the stream result are buffered
+		// by the provider before it closes textChan
+		if err := <-errChan; err != nil {
+			return AIErrorMsg{
+				generation: m.generation,
+				message:    types.Message{Role: "assistant", Content: formatAPIError(err)},
 			}
-			// Continue reading text
-			return m.readNextEnhancedChunk(textChan, funcChan, errChan)
-		case err := <-errChan:
-			if err != nil {
-				return AIResponseMsg{
-					message: types.Message{Role: "assistant", Content: fmt.Sprintf("Error: %v", err)},
-				}
+		}
+		result := <-resultChan
+		if len(result.FunctionCalls) > 0 {
+			return AIEnhancedStreamFunctionMsg{
+				generation:    m.generation,
+				functionCalls: result.FunctionCalls,
+				usage:         result.Usage,
 			}
-			return AIStreamCompleteMsg{}
 		}
+		return AIStreamCompleteMsg{generation: m.generation, usage: result.Usage}
 	}
 }
 
-func (m Model) getAIFunctionResponse(functionAPI api.FunctionAPI) tea.Cmd {
+func (m Model) getAIFunctionResponse(ctx context.Context, functionAPI api.FunctionAPI) tea.Cmd {
 	return func() tea.Msg {
-		// Filter out system messages for API calls
-		var apiMessages []types.Message
-		for _, msg := range m.messages {
-			if msg.Role != "system" {
-				apiMessages = append(apiMessages, msg)
-			}
-		}
+		// Summarized turns go in the system prompt, the rest as messages
+		_, apiMessages := m.contextMessages()
 		
 		// Use function calling
-		response, err := functionAPI.GetResponseWithFunctions(apiMessages, m.ai.SystemPrompt)
+		response, err := functionAPI.GetResponseWithFunctions(ctx, apiMessages, m.chatRequestConfig())
 		if err != nil {
-			// Clean up error message
-			errorContent := "❌ No API key configured. Please set GEMINI_API_KEY or GOOGLE_API_KEY, or update demo_api_key.txt"
-			if !strings.Contains(err.Error(), "API key") && 
-			   !strings.Contains(err.Error(), "Demo_Key_Replace") && 
-			   !strings.Contains(err.Error(), "invalid header field value") {
-				errorContent = fmt.Sprintf("❌ API Error: %v", err)
-			}
-			
 			return AIErrorMsg{
-				message: types.Message{
+				generation: m.generation,
+				message:    types.Message{
 					Role:    "assistant",
-					Content: errorContent,
+					Content: formatAPIError(err),
 				},
 			}
 		}
@@ -1028,31 +1116,194 @@ func (m Model) getAIFunctionResponse(functionAPI api.FunctionAPI) tea.Cmd {
 		// Handle function calls
 		if len(response.FunctionCalls) > 0 {
 			return AIFunctionCallMsg{
+				generation:    m.generation,
 				text:          response.Text,
 				functionCalls: response.FunctionCalls,
+				usage:         response.Usage,
 			}
 		}
 		
 		// Return normal text response
 		return AIResponseMsg{
-			message: types.Message{
+			generation: m.generation,
+			message:    types.Message{
 				Role:    "assistant",
 				Content: response.Text,
+				Usage:   response.Usage,
 			},
 		}
 	}
 }
 
-func (m Model) callAI(userInput string) tea.Cmd {
-	// Check for enhanced streaming (with function calls) first
-	if enhancedAPI, ok := m.aicore.API.(api.EnhancedStreamingAPI); ok {
-		return m.getEnhancedStreamingResponse(enhancedAPI)
-	} else if functionAPI, ok := m.aicore.API.(api.FunctionAPI); ok {
-		return m.getAIFunctionResponse(functionAPI)
-	} else if streamingAPI, ok := m.aicore.API.(api.StreamingAPI); ok {
-		return m.getAIStreamingResponse(streamingAPI)
-	} else {
-		return m.getAIResponse()
+// interruptedMarker is appended to responses the user stopped mid-stream
+const interruptedMarker = "\n\n⏹ [interrupted]"
+
+// startGeneration creates the context for a new request
+func (m *Model) startGeneration() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.generationCtx = ctx
+	m.cancelGeneration = cancel
+	m.generation++
+	m.toolRounds = 0
+	return ctx
+}
+
+// finishGeneration releases the context of the request that just completed
+func (m *Model) finishGeneration() {
+	if m.cancelGeneration != nil {
+		m.cancelGeneration()
+		m.cancelGeneration = nil
+		m.generationCtx = nil
+	}
+}
+
+// completeGeneration finishes the request and returns the switch to an AI
+// requested by a tool during it, if any, and the automatic compaction check
+func (m *Model) completeGeneration() tea.Cmd {
+	m.finishGeneration()
+	// Checked once the reply is stored, which happens after this returns
+	checkBudget := func() tea.Msg { return compactCheckMsg{} }
+	if m.pendingSwitch == "" {
+		return checkBudget
+	}
+	name := m.pendingSwitch
+	m.pendingSwitch = ""
+	return tea.Batch(checkBudget, func() tea.Msg {
+		return ManifestSuccessMsg{aiName: name}
+	})
+}
+
+// generating reports whether a request is in flight
+func (m Model) generating() bool {
+	return m.cancelGeneration != nil
+}
+
+// current reports whether a message belongs to the request in flight. A
+// stopped stream can still deliver its last chunk or completion after the
+// next request started.
+func (m Model) current(generation int) bool {
+	return m.generating() && generation == m.generation
+}
+
+// interruptGeneration cancels the in-flight request. Partial streamed text is
+// kept, marked as interrupted and saved like a finished response.
+func (m Model) interruptGeneration() Model {
+	m.finishGeneration()
+
+	if n := len(m.messages); n > 0 && m.messages[n-1].Role == "assistant" {
+		if m.messages[n-1].Content == "" {
+			// Nothing arrived yet, drop the placeholder
+			m.messages = m.messages[:n-1]
+		} else {
+			m.messages[n-1].Content += interruptedMarker
+			if err := m.saveLastMessage(); err != nil {
+				m.messages = append(m.messages, types.Message{
+					Role:    "system",
+					Content: fmt.Sprintf("⚠️ Failed to save interrupted message: %v", err),
+				})
+			}
+		}
+	}
+
+	// Calls that never got a result would make the history invalid for the provider
+	for _, call := range unansweredCalls(m.messages) {
+		result := types.Message{
+			Role:           "tool",
+			Content:        "interrupted by the user before the function returned",
+			FunctionCallID: call.ID,
+			FunctionName:   call.Name,
+		}
+		m.messages = append(m.messages, result)
+		if err := m.saveLastMessage(); err != nil {
+			m.messages = append(m.messages, types.Message{
+				Role:    "system",
+				Content: fmt.Sprintf("⚠️ Failed to save function result: %v", err),
+			})
+		}
+	}
+
+	if m.pendingSwitch != "" {
+		m.messages = append(m.messages, types.Message{
+			Role:    "system",
+			Content: fmt.Sprintf("✨ %s is ready, switch to them with /set ai", m.pendingSwitch),
+		})
+		m.pendingSwitch = ""
+	}
+
+	m.statusPanel.status = AtEase
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	return m
+}
+
+// requestConfig builds the per-request settings from the active AI
+func (m Model) requestConfig() api.RequestConfig {
+	// Stored params are validated by /set param, fall back to provider defaults if not
+	params, _ := api.DecodeParams(m.ai.ParamsJSON)
+	return api.RequestConfig{
+		API:          m.ai.API,
+		Model:        m.ai.Model,
+		SystemPrompt: m.ai.SystemPrompt,
+		Params:       params,
+	}
+}
+
+// chatRequestConfig is requestConfig plus the active AI's memories, the
+// conversation summary and tools
+func (m Model) chatRequestConfig() api.RequestConfig {
+	config := m.requestConfig()
+	config.SystemPrompt = m.promptWithMemories(config.SystemPrompt)
+	if summary, _ := m.contextMessages(); summary != "" {
+		config.SystemPrompt += "\n\nSummary of the earlier conversation:\n" + summary
+	}
+	config.Functions = tools.Declarations(m.enabledTools())
+	return config
+}
+
+// formatAPIError turns a provider error into a chat-friendly message
+func formatAPIError(err error) string {
+	var unavailableErr *ai.UnavailableError
+	if errors.As(err, &unavailableErr) {
+		return fmt.Sprintf("❌ %s is unavailable: %v\nSwitch with /set api or /set ai", unavailableErr.API, unavailableErr.Err)
+	}
+	var modelErr *api.ModelError
+	if errors.As(err, &modelErr) {
+		return fmt.Sprintf("❌ Model '%s' was rejected by %s. Pick another one with /set model", modelErr.Model, modelErr.API)
+	}
+	if strings.Contains(err.Error(), "API key") ||
+		strings.Contains(err.Error(), "Demo_Key_Replace") ||
+		strings.Contains(err.Error(), "invalid header field value") {
+		return "❌ No API key configured. Please set GEMINI_API_KEY or GOOGLE_API_KEY, update demo_api_key.txt, or add a key source under [providers.gemini] in config.toml"
+	}
+	return fmt.Sprintf("❌ API Error: %v", err)
+}
+
+func (m Model) callAI(ctx context.Context, userInput string) tea.Cmd {
+	return func() tea.Msg {
+		// Resolve the backend for the active AI, a key command may run here
+		provider, err := m.aicore.Provider(m.ai.API)
+		if err != nil {
+			return AIErrorMsg{
+				generation: m.generation,
+				message: types.Message{
+					Role:    "assistant",
+					Content: formatAPIError(err),
+				},
+			}
+		}
+
+		// Check for enhanced streaming (with function calls) first
+		if enhancedAPI, ok := provider.(api.EnhancedStreamingAPI); ok {
+			return m.getEnhancedStreamingResponse(ctx, enhancedAPI)()
+		} else if functionAPI, ok := provider.(api.FunctionAPI); ok {
+			return m.getAIFunctionResponse(ctx, functionAPI)()
+		} else if streamingAPI, ok := provider.(api.StreamingAPI); ok {
+			return m.getAIStreamingResponse(ctx, streamingAPI)()
+		} else {
+			return m.getAIResponse(ctx, provider)()
+		}
 	}
 }
 
@@ -1076,6 +1327,17 @@ func (m Model) makeInfoPanel() string {
 		Foreground(lipgloss.Color(statusColor)).
 		Bold(true)
 
+	sessionSummary := formatUsage(m.sessionUsage)
+	if m.sessionCost > 0 {
+		sessionSummary += fmt.Sprintf("\n~$%.4f", m.sessionCost)
+	}
+
+	// Only the params that are set, provider defaults otherwise
+	paramsSummary := "default"
+	if params, err := api.DecodeParams(m.ai.ParamsJSON); err == nil && len(params.Fields()) > 0 {
+		paramsSummary = strings.Join(params.Fields(), "\n")
+	}
+
 	// Combine time styling and centering
 	centerTimeStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color(m.palette[5])).
@@ -1095,25 +1357,36 @@ func (m Model) makeInfoPanel() string {
 		"",
 		fmt.Sprintf("%s %s", m.labelStyle().Render("model:"), m.valueStyle().Render(m.ai.Model)),
 		"",
+		fmt.Sprintf("%s\n%s", m.labelStyle().Render("params:"), m.valueStyle().Render(paramsSummary)),
+		"",
 		fmt.Sprintf("%s %s", m.labelStyle().Render("conv:"), m.valueStyle().Render(conversationName)),
 		"",
+		fmt.Sprintf("%s\n%s", m.labelStyle().Render("tokens (conv):"), m.valueStyle().Render(formatUsage(m.conversationUsage()))),
+		"",
+		fmt.Sprintf("%s\n%s", m.labelStyle().Render("tokens (session):"), m.valueStyle().Render(sessionSummary)),
+		"",
 		fmt.Sprintf("%s %s", m.labelStyle().Render("status:"), statusStyle.Render(m.apiStatus.String())),
 		"",
 	)
 }
 
-func (m Model) makeStatusPanel() string {
+// statusLine is the status icon and what is going on
+func (m Model) statusLine() string {
 	var icon, text, color string
 
 	switch m.statusPanel.status {
 	case AtEase:
 		icon, text, color = "●", "", "10"
 	case Processing:
-		icon, text, color = "🤔", "processing...", "11"
+		icon, text, color = "🤔", "processing... (esc to stop)", "11"
 	case Typing:
 		icon, text, color = "✎", "typing..", "12"
 	case Manifesting:
 		icon, text, color = "🔮", fmt.Sprintf("manifesting %s", m.statusPanel.manifestingName), "13"
+	case UsingTools:
+		icon, text, color = "🔧", fmt.Sprintf("%s... (esc to stop)", m.statusPanel.activity), "13"
+	case Compacting:
+		icon, text, color = "📝", "compacting...", "13"
 	case Error:
 		icon, text, color = "✗", "error", "9"
 	}
@@ -1122,20 +1395,19 @@ func (m Model) makeStatusPanel() string {
 		Foreground(lipgloss.Color(color)).
 		Bold(true)
 
-	var content string
 	if text == "" {
-		content = statusStyle.Render(icon)
-	} else {
-		content = statusStyle.Render(fmt.Sprintf("%s %s", icon, text))
+		return statusStyle.Render(icon)
 	}
+	return statusStyle.Render(fmt.Sprintf("%s %s", icon, text))
+}
 
+func (m Model) makeStatusPanel() string {
 	// Fixed height for status panel (like textarea height)
-	statusPanelHeight := 3
 	panelStyle := lipgloss.NewStyle().
 		Height(statusPanelHeight).
 		AlignVertical(lipgloss.Center)
 
-	return panelStyle.Render(content)
+	return panelStyle.Render(m.statusLine())
 }
 
 
@@ -1200,7 +1472,7 @@ func (m Model) handleSlashCommand(command string) (tea.Model, tea.Cmd) {
 			return m.listAPIs()
 		case "model", "models":
 			if len(parts) < 3 {
-				return m.showError("Usage: /list model(s) [gemini]")
+				return m.showError("Usage: /list model(s) <api>")
 			}
 			return m.listModels(parts[2])
 		default:
@@ -1209,7 +1481,7 @@ func (m Model) handleSlashCommand(command string) (tea.Model, tea.Cmd) {
 		
 	case "set":
 		if len(parts) < 2 {
-			return m.showError("Usage: /set [ai|api|model|prompt]")
+			return m.showError("Usage: /set [ai|api|model|prompt|param]")
 		}
 		switch parts[1] {
 		case "ai":
@@ -1228,6 +1500,11 @@ func (m Model) handleSlashCommand(command string) (tea.Model, tea.Cmd) {
 			// Join all parts after "prompt" to get the full prompt text
 			promptText := strings.Join(parts[2:], " ")
 			return m.setPrompt(promptText)
+		case "param":
+			if len(parts) < 3 {
+				return m.showError("Usage: /set param <name> [value|default] (" + strings.Join(api.ParamNames, ", ") + ")")
+			}
+			return m.setParam(parts[2], parts[3:])
 		default:
 			return m.showError("Unknown set type: " + parts[1])
 		}
@@ -1235,6 +1512,12 @@ func (m Model) handleSlashCommand(command string) (tea.Model, tea.Cmd) {
 	case "resume":
 		return m.listConversations()
 		
+	case "search":
+		if len(parts) < 2 {
+			return m.showError("Usage: /search <words>")
+		}
+		return m.searchMessages(strings.Join(parts[1:], " "))
+		
 	case "clear":
 		return m.clearConversation()
 		
@@ -1268,6 +1551,39 @@ func (m Model) handleSlashCommand(command string) (tea.Model, tea.Cmd) {
 		imageURL := parts[2]
 		return m.manifest(aiName, imageURL)
 		
+	case "tools":
+		return m.toolsCommand(parts[1:])
+		
+	case "usage":
+		return m.showUsage(parts[1:])
+		
+	case "export":
+		return m.exportCommand(parts[1:])
+		
+	case "import":
+		return m.importCommand(parts[1:])
+		
+	case "fork":
+		return m.openForkSelector()
+		
+	case "retry":
+		return m.retry()
+		
+	case "edit":
+		return m.startEdit()
+		
+	case "branch":
+		return m.branchCommand(parts[1:])
+	case "memory":
+		return m.memoryCommand(parts[1:])
+	case "compact":
+		return m.compact(false)
+	case "layout":
+		return m.layoutCommand(parts[1:])
+		
+	case "ai":
+		return m.aiCommand(parts[1:])
+		
 	case "quit":
 		return m, tea.Quit
 		
@@ -1,8 +1,11 @@
 package chat
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/curator4/io-tui/api"
@@ -35,6 +38,9 @@ type apiItem struct {
 func (a apiItem) FilterValue() string { return a.name }
 func (a apiItem) Title() string       { return a.info.Name }
 func (a apiItem) Description() string { 
+	if len(a.info.Models) == 0 {
+		return fmt.Sprintf("Default: %s (models discovered from server)", a.info.DefaultModel)
+	}
 	return fmt.Sprintf("Default: %s (%d models available)", a.info.DefaultModel, len(a.info.Models))
 }
 
@@ -131,16 +137,50 @@ func (m Model) openModelSelector() (tea.Model, tea.Cmd) {
 		return m.showError("Current API not found in available APIs")
 	}
 	
-	var items []list.Item
-	for _, model := range apiInfo.Models {
-		items = append(items, modelItem{name: model, api: m.ai.API})
+	apiName := m.ai.API
+	return m, m.loadModels(apiName, func(m Model, models []string) (tea.Model, tea.Cmd) {
+		var items []list.Item
+		for _, model := range models {
+			items = append(items, modelItem{name: model, api: apiName})
+		}
+		
+		m.list.SetItems(items)
+		m.list.Title = fmt.Sprintf("Select %s Model (Enter to switch, Esc to cancel)", apiInfo.Name)
+		m.viewMode = listMode
+		
+		return m, nil
+	})
+}
+
+// modelsMsg carries an api's models, fetched in the background, and what
+// the command that asked for them does next
+type modelsMsg struct {
+	models []string
+	err    error
+	then   func(Model, []string) (tea.Model, tea.Cmd)
+}
+
+// modelsTimeout bounds asking a provider for its models
+const modelsTimeout = 10 * time.Second
+
+// loadModels lists an api's models off the update loop, ollama asks its
+// server and resolving a provider may run a key command
+func (m Model) loadModels(apiName string, then func(Model, []string) (tea.Model, tea.Cmd)) tea.Cmd {
+	aicore := m.aicore
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), modelsTimeout)
+		defer cancel()
+		models, err := aicore.Models(ctx, apiName)
+		return modelsMsg{models: models, err: err, then: then}
 	}
-	
-	m.list.SetItems(items)
-	m.list.Title = fmt.Sprintf("Select %s Model (Enter to switch, Esc to cancel)", apiInfo.Name)
-	m.viewMode = listMode
-	
-	return m, nil
+}
+
+// modelsLoaded hands the models to the command that asked for them
+func (m Model) modelsLoaded(msg modelsMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m.showError("Error loading models: " + msg.err.Error())
+	}
+	return msg.then(m, msg.models)
 }
 
 func (m Model) listConversations() (tea.Model, tea.Cmd) {
@@ -193,22 +233,24 @@ func (m Model) listModels(apiName string) (tea.Model, tea.Cmd) {
 		return m.showError("Unknown API: " + apiName)
 	}
 	
-	var items []list.Item
-	for _, model := range apiInfo.Models {
-		items = append(items, modelItem{name: model, api: apiName})
-	}
-	
-	m.list.SetItems(items)
-	m.list.Title = fmt.Sprintf("%s Models (Esc to close)", apiInfo.Name)
-	
-	// Configure for view-only mode
-	m.list.SetShowStatusBar(false)
-	m.list.SetFilteringEnabled(true)
-	m.list.SetShowHelp(true)
-	
-	m.viewMode = listMode
-	
-	return m, nil
+	return m, m.loadModels(apiName, func(m Model, models []string) (tea.Model, tea.Cmd) {
+		var items []list.Item
+		for _, model := range models {
+			items = append(items, modelItem{name: model, api: apiName})
+		}
+		
+		m.list.SetItems(items)
+		m.list.Title = fmt.Sprintf("%s Models (Esc to close)", apiInfo.Name)
+		
+		// Configure for view-only mode
+		m.list.SetShowStatusBar(false)
+		m.list.SetFilteringEnabled(true)
+		m.list.SetShowHelp(true)
+		
+		m.viewMode = listMode
+		
+		return m, nil
+	})
 }
 
 // Set functions
@@ -217,20 +259,18 @@ func (m Model) setAI(name string) (tea.Model, tea.Cmd) {
 	if m.ai.Name == name {
 		return m.showError(fmt.Sprintf("AI '%s' is already active! 🎯", name))
 	}
-	
+	// A reply still streaming belongs to the conversation being left
+	if m.generating() {
+		m = m.interruptGeneration()
+	}
+
 	newAI, err := db.SetActiveAI(m.database, name)
 	if err != nil {
 		return m.showError("Error switching to AI '" + name + "': " + err.Error())
 	}
 	
-	// Update model with new AI
-	m.ai = newAI
-	
-	// Load new ASCII art and palette
-	newAI.Ascii = strings.ReplaceAll(newAI.Ascii, "[0m", "\033[0m")
-	newAI.Ascii = strings.ReplaceAll(newAI.Ascii, "[38;2;", "\033[38;2;")
-	m.ascii = strings.TrimSpace(newAI.Ascii)
-	updateModelPalette(&m)
+	// Update model with new AI, its ASCII art and palette
+	m.useAI(newAI)
 	
 	// Clear active conversation since we switched AIs
 	m.conversation = db.Conversation{}
@@ -250,17 +290,65 @@ func (m Model) setAI(name string) (tea.Model, tea.Cmd) {
 	return m, m.getAIIntroduction()
 }
 
+// useAI makes newAI the active AI of the model, loading its ASCII art and palette
+func (m *Model) useAI(newAI db.AI) {
+	m.ai = newAI
+	newAI.Ascii = strings.ReplaceAll(newAI.Ascii, "[0m", "\033[0m")
+	newAI.Ascii = strings.ReplaceAll(newAI.Ascii, "[38;2;", "\033[38;2;")
+	m.ascii = strings.TrimSpace(newAI.Ascii)
+	updateModelPalette(m)
+}
+
+// apiCheckedMsg reports whether an api /set api picked can be used and
+// which model to start it with
+type apiCheckedMsg struct {
+	name  string
+	model string
+	err   error
+}
+
 func (m Model) setAPI(apiName string) (tea.Model, tea.Cmd) {
 	// Check if API exists
 	apiInfo, exists := api.AvailableAPIs[apiName]
 	if !exists {
 		return m.showError("Unknown API: " + apiName)
 	}
-	
+	m.viewMode = chatMode
+
+	// Resolving the provider may run a key command, and listing models
+	// asks the server, so both happen off the update loop
+	aicore := m.aicore
+	return m, func() tea.Msg {
+		// Make sure the provider can actually be used before switching
+		provider, err := aicore.Provider(apiName)
+		if err != nil {
+			return apiCheckedMsg{name: apiName, err: err}
+		}
+
+		// Prefer the default model, unless the provider reports it isn't installed
+		model := apiInfo.DefaultModel
+		if lister, ok := provider.(api.ModelLister); ok {
+			if models, err := lister.ListModels(context.Background()); err == nil && len(models) > 0 && !slices.Contains(models, model) {
+				model = models[0]
+			}
+		}
+		return apiCheckedMsg{name: apiName, model: model}
+	}
+}
+
+// switchAPI moves the active AI to the api setAPI checked
+func (m Model) switchAPI(msg apiCheckedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m.showError(fmt.Sprintf("Can't switch to API '%s': %v", msg.name, msg.err))
+	}
+	if m.generating() {
+		m = m.interruptGeneration()
+	}
+
 	// Update the active AI's API and set to default model
-	updatedAI, err := db.UpdateActiveAIAPI(m.database, apiName, apiInfo.DefaultModel)
+	updatedAI, err := db.UpdateActiveAIAPI(m.database, msg.name, msg.model)
 	if err != nil {
-		return m.showError("Error switching to API '" + apiName + "': " + err.Error())
+		return m.showError("Error switching to API '" + msg.name + "': " + err.Error())
 	}
 	
 	// Update model with new AI info
@@ -273,38 +361,39 @@ func (m Model) setAPI(apiName string) (tea.Model, tea.Cmd) {
 	m.messages = []types.Message{}
 	successMsg := types.Message{
 		Role:    "system",
-		Content: fmt.Sprintf("Switched to API: %s (Model: %s)", apiInfo.Name, apiInfo.DefaultModel),
+		Content: fmt.Sprintf("Switched to API: %s (Model: %s)", api.AvailableAPIs[msg.name].Name, msg.model),
 	}
 	m.messages = append(m.messages, successMsg)
 	m.viewport.SetContent(m.formatMessages())
 	m.viewport.GotoBottom()
 	
-	// Return to chat mode
-	m.viewMode = chatMode
-	
 	return m, nil
 }
 
 func (m Model) setModel(modelName string) (tea.Model, tea.Cmd) {
 	// Check if model exists in current API
-	apiInfo, exists := api.AvailableAPIs[m.ai.API]
-	if !exists {
+	if _, exists := api.AvailableAPIs[m.ai.API]; !exists {
 		return m.showError("Current API not found in available APIs")
 	}
 	
-	// Check if model is available for current API
-	modelExists := false
-	for _, availableModel := range apiInfo.Models {
-		if availableModel == modelName {
-			modelExists = true
-			break
+	apiName := m.ai.API
+	return m, m.loadModels(apiName, func(m Model, models []string) (tea.Model, tea.Cmd) {
+		// The api may have changed while the models loaded
+		if m.ai.API != apiName {
+			return m.showError(fmt.Sprintf("Switched away from API '%s' before model '%s' was set", apiName, modelName))
 		}
+		if !slices.Contains(models, modelName) {
+			return m.showError(fmt.Sprintf("Model '%s' not available for API '%s'", modelName, apiName))
+		}
+		return m.useModel(modelName)
+	})
+}
+
+// useModel switches the active AI to a model setModel checked
+func (m Model) useModel(modelName string) (tea.Model, tea.Cmd) {
+	if m.generating() {
+		m = m.interruptGeneration()
 	}
-	
-	if !modelExists {
-		return m.showError(fmt.Sprintf("Model '%s' not available for API '%s'", modelName, m.ai.API))
-	}
-	
 	// Update the active AI's model
 	updatedAI, err := db.UpdateActiveAIModel(m.database, modelName)
 	if err != nil {
@@ -334,27 +423,35 @@ func (m Model) setModel(modelName string) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) resumeConversation(conversationID int) (tea.Model, tea.Cmd) {
+	return m.resumeConversationAt(conversationID, 0)
+}
+
+// resumeConversationAt resumes on the newest branch through messageID,
+// the newest branch of all when messageID is 0
+func (m Model) resumeConversationAt(conversationID, messageID int) (tea.Model, tea.Cmd) {
+	if m.generating() {
+		m = m.interruptGeneration()
+	}
 	// Set this conversation as active
 	conversation, err := db.SetActiveConversation(m.database, conversationID)
 	if err != nil {
 		return m.showError("Error resuming conversation: " + err.Error())
 	}
 	
-	// Load messages for this conversation
-	dbMessages, err := db.LoadMessages(m.database, conversationID)
+	// Load every branch, show the one last written to
+	tree, err := db.LoadMessages(m.database, conversationID)
 	if err != nil {
 		return m.showError("Error loading conversation messages: " + err.Error())
 	}
+	if err := checkFunctionRows(tree); err != nil {
+		return m.showError("Error loading conversation messages: " + err.Error())
+	}
+	leafID := db.LatestLeaf(tree, messageID)
+	dbMessages := db.Branch(tree, leafID)
+	m.branch = newBranchState(conversationID, tree, leafID)
 	
 	// Convert db.Message to types.Message
-	m.messages = []types.Message{}
-	for _, dbMsg := range dbMessages {
-		typeMsg := types.Message{
-			Role:    dbMsg.Role,
-			Content: dbMsg.Content,
-		}
-		m.messages = append(m.messages, typeMsg)
-	}
+	m.messages = messagesFromDB(dbMessages)
 	
 	// Update model with resumed conversation
 	m.conversation = conversation
@@ -387,6 +484,9 @@ func (m Model) resumeConversation(conversationID int) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) clearConversation() (tea.Model, tea.Cmd) {
+	if m.generating() {
+		m = m.interruptGeneration()
+	}
 	// Clear active conversation in database
 	err := db.ClearActiveConversations(m.database)
 	if err != nil {
@@ -406,6 +506,43 @@ func (m Model) clearConversation() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) setParam(name string, values []string) (tea.Model, tea.Cmd) {
+	params, err := api.DecodeParams(m.ai.ParamsJSON)
+	if err != nil {
+		return m.showError("Error reading params: " + err.Error())
+	}
+	if err := params.Set(name, values...); err != nil {
+		return m.showError("❌ " + err.Error())
+	}
+	paramsJSON, err := params.Encode()
+	if err != nil {
+		return m.showError("Error saving params: " + err.Error())
+	}
+	
+	// Update the active AI's params in database
+	updatedAI, err := db.UpdateActiveAIParams(m.database, paramsJSON)
+	if err != nil {
+		return m.showError("Error updating params: " + err.Error())
+	}
+	m.ai = updatedAI
+	
+	summary := params.String()
+	if summary == "" {
+		summary = "provider defaults"
+	}
+	successMsg := types.Message{
+		Role:    "system",
+		Content: fmt.Sprintf("✨ Params for %s: %s", updatedAI.Name, summary),
+	}
+	m.messages = append(m.messages, successMsg)
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	
+	return m, nil
+}
+
 func (m Model) setPrompt(promptText string) (tea.Model, tea.Cmd) {
 	// Update the active AI's prompt in database
 	updatedAI, err := db.UpdateActiveAIPrompt(m.database, promptText)
@@ -472,14 +609,39 @@ func (m Model) showCommands() (tea.Model, tea.Cmd) {
   /set api                 - Open API selector (interactive)
   /set model               - Open model selector (interactive)
   /set prompt <text>       - Update AI system prompt
+  /set param <name> <value> - Set temperature, top_p, top_k, max_tokens,
+                             stop or seed ("default" to unset)
+  /tools                   - Show which tools the AI may call
+  /tools enable|disable <name> - Allow or forbid a tool for this AI
+  /tools only <names...>   - Allow only the named tools
+  /tools reset             - Allow every tool again
+  /ai export <name> [path] - Save an AI (prompt, model, art, palette) to a file
+  /ai import <path> [name] - Add an AI from a file or character card (.json/.png)
+  #<text>                  - Make the active AI remember something
+  /memory [list]           - Show what the active AI remembers
+  /memory delete <id>      - Forget a memory
+  /memory edit <id> <text> - Rewrite a memory
 
 💬 Conversations:
   /resume                  - List and resume previous conversations
   /clear                   - Clear current conversation
   /rename <name>           - Rename current conversation
+  /retry                   - Regenerate the last response, keeping the old one
+  /edit                    - Edit and resend your last message
+  /fork                    - Pick a message to continue from on a new branch
+  /branch prev|next        - Switch between branches (alt+left/right by default)
+  /compact                 - Summarize older messages to keep requests small
+  /search <words>          - Find messages in every conversation
+  /export [md|json|html] [path] - Save this conversation to a file
+  /export all [format] [path]   - Save every conversation to one file
+  /import <path> [ai name] - Import a ChatGPT, Claude or io-tui JSON export
+  ctrl+s                   - Select messages to copy (y message, c/1-9 code block)
 
 🔍 Information:
   /show prompt             - Display current AI system prompt
+  /usage [from] [to]       - Tokens and estimated cost (dates YYYY-MM-DD)
+  /layout [auto|stacked|side] - Put the art and info above or beside the chat
+  /layout art|info         - Hide or show a panel
   /commands, /help         - Show this help message
 
 🚪 Exit:
@@ -550,109 +712,78 @@ func (m Model) manifest(name, imageURL string) (tea.Model, tea.Cmd) {
 	return m, m.processManifest(name, imageURL)
 }
 
-func (m Model) manifestWithDescription(name, imageURL, description string) (tea.Model, tea.Cmd) {
-	// Set status to manifesting
-	m.statusPanel.status = Manifesting
-	m.statusPanel.manifestingName = name
-	
-	return m, m.processManifestWithDescription(name, imageURL, description)
-}
-
 func (m Model) processManifest(name, imageURL string) tea.Cmd {
 	return func() tea.Msg {
-		// Call visual package to generate palette and ASCII
-		palette, ascii, err := visual.GenerateFromImageURL(imageURL)
+		warning, err := m.createManifestedAI(context.Background(), name, imageURL, "")
 		if err != nil {
 			return ManifestErrorMsg{
 				message: types.Message{
 					Role:    "system",
-					Content: fmt.Sprintf("🔥 Manifest ritual failed: %v", err),
-				},
-			}
-		}
-		
-		// Convert palette to JSON for database
-		paletteJSON, err := visual.FormatPaletteForDB(palette)
-		if err != nil {
-			return ManifestErrorMsg{
-				message: types.Message{
-					Role:    "system",
-					Content: "🔥 Failed to format color palette",
-				},
-			}
-		}
-		
-		// Create basic system prompt
-		systemPrompt := fmt.Sprintf("You are %s, a helpful AI assistant.", name)
-		
-		// Create AI in database with new fields
-		err = db.CreateAI(m.database, name, systemPrompt, "gemini", "gemini-2.0-flash-exp", ascii, paletteJSON, false)
-		if err != nil {
-			return ManifestErrorMsg{
-				message: types.Message{
-					Role:    "system",
-					Content: fmt.Sprintf("🔥 Failed to save character to database: %v", err),
+					Content: fmt.Sprintf("🔥 %v", err),
 				},
 			}
 		}
 		
 		// Return success with AI name for automatic switching
 		return ManifestSuccessMsg{
-			aiName: name,
+			aiName:  name,
+			warning: warning,
 		}
 	}
 }
 
-func (m Model) processManifestWithDescription(name, imageURL, description string) tea.Cmd {
-	return func() tea.Msg {
-		// Call visual package to generate palette and ASCII
-		palette, ascii, err := visual.GenerateFromImageURL(imageURL)
-		if err != nil {
-			return ManifestErrorMsg{
-				message: types.Message{
-					Role:    "system",
-					Content: fmt.Sprintf("🔥 Manifest ritual failed: %v", err),
-				},
-			}
-		}
-		
-		// Convert palette to JSON for database
-		paletteJSON, err := visual.FormatPaletteForDB(palette)
-		if err != nil {
-			return ManifestErrorMsg{
-				message: types.Message{
-					Role:    "system",
-					Content: "🔥 Failed to format color palette",
-				},
-			}
-		}
-		
-		// Generate character-specific system prompt using AI
-		systemPrompt, err := m.generateSystemPrompt(name, description)
+// createManifestedAI renders the image and saves a new character, with a
+// system prompt generated from the description when there is one. Shared by
+// /manifest and the manifest_character function.
+func (m Model) createManifestedAI(ctx context.Context, name, imageURL, description string) (warning string, err error) {
+	// Call visual package to generate palette and ASCII
+	palette, ascii, image, err := visual.GenerateFromImageURL(imageURL)
+	if err != nil {
+		return "", fmt.Errorf("Manifest ritual failed: %w", err)
+	}
+	
+	// Convert palette to JSON for database
+	paletteJSON, err := visual.FormatPaletteForDB(palette)
+	if err != nil {
+		return "", fmt.Errorf("Failed to format color palette: %w", err)
+	}
+	
+	// Generate character-specific system prompt using AI
+	systemPrompt := fmt.Sprintf("You are %s, a helpful AI assistant.", name)
+	if description != "" {
+		systemPrompt, err = m.generateSystemPrompt(ctx, name, description)
 		if err != nil {
 			// Fallback to basic prompt if generation fails
 			systemPrompt = fmt.Sprintf("You are %s. %s\n\nBe concise and direct in your responses. Avoid lengthy explanations unless specifically asked.", name, description)
 		}
-		
-		// Create AI in database with generated prompt
-		err = db.CreateAI(m.database, name, systemPrompt, "gemini", "gemini-2.0-flash-exp", ascii, paletteJSON, false)
-		if err != nil {
-			return ManifestErrorMsg{
-				message: types.Message{
-					Role:    "system",
-					Content: fmt.Sprintf("🔥 Failed to save character to database: %v", err),
-				},
-			}
-		}
-		
-		// Return success with AI name for automatic switching
-		return ManifestSuccessMsg{
-			aiName: name,
-		}
 	}
+	
+	// Create AI in database with generated prompt
+	apiName, model := m.manifestTarget()
+	if err := db.CreateAI(m.database, name, systemPrompt, apiName, model, ascii, paletteJSON, false); err != nil {
+		return "", fmt.Errorf("Failed to save character to database: %w", err)
+	}
+	if err := storeAIImage(m.database, name, image); err != nil {
+		return imageWarning(err), nil
+	}
+	return "", nil
+}
+
+// manifestTarget is the api/model new characters use: [manifest] in the
+// config, or the same as the AI doing the manifesting
+func (m Model) manifestTarget() (string, string) {
+	apiName := m.config.Manifest.API
+	if apiName == "" {
+		return m.ai.API, m.ai.Model
+	}
+	model := m.config.Manifest.Model
+	if model == "" {
+		model = api.AvailableAPIs[apiName].DefaultModel
+	}
+	return apiName, model
 }
 
-func (m Model) generateSystemPrompt(name, description string) (string, error) {
+func (m Model) generateSystemPrompt(ctx context.Context, name, description string) (string, error) {
 	// Create a prompt to generate the character's system prompt
 	promptGenerationMessages := []types.Message{
 		{
@@ -672,7 +803,13 @@ Return only the system prompt, nothing else.`, name, description, name),
 	}
 	
 	// Generate system prompt using current AI
-	generatedPrompt, err := m.aicore.API.GetResponse(promptGenerationMessages, "You are a helpful assistant that creates character system prompts. Be concise and precise.")
+	config := m.requestConfig()
+	config.SystemPrompt = "You are a helpful assistant that creates character system prompts. Be concise and precise."
+	provider, err := m.aicore.Provider(m.ai.API)
+	if err != nil {
+		return "", err
+	}
+	generatedPrompt, err := provider.GetResponse(ctx, promptGenerationMessages, config)
 	if err != nil {
 		return "", err
 	}
@@ -693,6 +830,19 @@ func (m Model) showError(msg string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// showInfo adds a system message to the chat
+func (m Model) showInfo(text string) (tea.Model, tea.Cmd) {
+	m.messages = append(m.messages, types.Message{
+		Role:    "system",
+		Content: text,
+	})
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	return m, nil
+}
+
 func (m Model) getAIIntroduction() tea.Cmd {
 	return func() tea.Msg {
 		// Create a simple introduction prompt
@@ -703,7 +853,17 @@ func (m Model) getAIIntroduction() tea.Cmd {
 			},
 		}
 		
-		response, err := m.aicore.API.GetResponse(introMessages, m.ai.SystemPrompt)
+		provider, err := m.aicore.Provider(m.ai.API)
+		if err != nil {
+			return AIIntroductionMsg{
+				message: types.Message{
+					Role:    "system",
+					Content: formatAPIError(err),
+				},
+			}
+		}
+		
+		response, err := provider.GetResponse(context.Background(), introMessages, m.requestConfig())
 		if err != nil {
 			return AIIntroductionMsg{
 				message: types.Message{
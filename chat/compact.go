@@ -0,0 +1,216 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/api"
+	"github.com/curator4/io-tui/types"
+)
+
+// summaryRole rows hold a summary of the turns before them. Requests send the
+// newest summary plus the last compactKeepTurns user turns before it and
+// everything after it, instead of the whole conversation.
+const summaryRole = "summary"
+
+// compactKeepTurns is how many recent user turns stay out of a summary. It is
+// fixed so stored summaries keep covering the same messages.
+const compactKeepTurns = 4
+
+// compactTimeout bounds the summary request
+const compactTimeout = 2 * time.Minute
+
+const compactPrompt = `You compress chat histories. Summarize the conversation you are given so it can continue without the original messages.
+Keep names, facts, decisions, open questions, code and file names, and anything the user asked to remember. Drop small talk and repetition.
+Write in the third person ("the user", "the assistant"), as plain text, as briefly as the content allows.`
+
+// compactCheckMsg asks for a token budget check once a reply is stored
+type compactCheckMsg struct{}
+
+// CompactDoneMsg carries a summary written in the background
+type CompactDoneMsg struct {
+	conversationID int
+	// branch head when the summary was requested, it only applies there
+	headID  int
+	summary string
+	// messages the summary replaces
+	compacted int
+	auto      bool
+	err       error
+}
+
+// contextMessages splits the conversation into the newest summary and the
+// messages still sent in full. Display-only system messages are dropped.
+func (m Model) contextMessages() (summary string, recent []types.Message) {
+	for _, msg := range m.messages {
+		switch msg.Role {
+		case "system":
+		case summaryRole:
+			recent = recent[compactSplit(recent):]
+			summary = msg.Content
+		default:
+			recent = append(recent, msg)
+		}
+	}
+	return summary, recent
+}
+
+// compactSplit is where the kept tail of messages starts: at the
+// compactKeepTurns-th last user message, so function calls and their
+// results are never separated
+func compactSplit(messages []types.Message) int {
+	turns := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			turns++
+			if turns == compactKeepTurns {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// compact summarizes everything but the last few turns in the background:
+// /compact, or automatically once the model's token budget is exceeded
+func (m Model) compact(auto bool) (tea.Model, tea.Cmd) {
+	if m.compacting {
+		return m.showError("Already compacting")
+	}
+	if m.generating() {
+		return m.showError("Wait for the response to finish before compacting")
+	}
+	if m.conversation.ID == 0 {
+		return m.showError("No conversation to compact")
+	}
+
+	summary, recent := m.contextMessages()
+	older := recent[:compactSplit(recent)]
+	if len(older) == 0 {
+		return m.showError(fmt.Sprintf("Nothing to compact yet, the last %d turns are always kept", compactKeepTurns))
+	}
+
+	m.compacting = true
+	m.statusPanel.status = Compacting
+
+	transcript := compactTranscript(summary, older, m.ai.Name)
+	params, _ := api.DecodeParams(m.ai.ParamsJSON)
+	config := api.RequestConfig{
+		API:          m.ai.API,
+		Model:        m.ai.Model,
+		SystemPrompt: compactPrompt,
+		Params:       params,
+	}
+	done := CompactDoneMsg{
+		conversationID: m.conversation.ID,
+		headID:         m.branch.headID,
+		compacted:      len(older),
+		auto:           auto,
+	}
+	aicore, apiName := m.aicore, m.ai.API
+	return m, func() tea.Msg {
+		provider, err := aicore.Provider(apiName)
+		if err != nil {
+			done.err = err
+			return done
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), compactTimeout)
+		defer cancel()
+		request := []types.Message{{Role: "user", Content: transcript}}
+		done.summary, done.err = provider.GetResponse(ctx, request, config)
+		if done.err == nil && strings.TrimSpace(done.summary) == "" {
+			done.err = fmt.Errorf("the model returned an empty summary")
+		}
+		return done
+	}
+}
+
+// autoCompact starts a compaction when the next request would exceed the
+// model's token budget
+func (m Model) autoCompact() (tea.Model, tea.Cmd) {
+	if !m.config.Compact.Auto || m.compacting || m.generating() || m.conversation.ID == 0 {
+		return m, nil
+	}
+	budget := api.TokenBudget(m.ai.API, m.ai.Model)
+	if budget == 0 {
+		return m, nil
+	}
+	_, recent := m.contextMessages()
+	if estimateTokens(m.chatRequestConfig().SystemPrompt, recent) <= budget || compactSplit(recent) == 0 {
+		return m, nil
+	}
+	return m.compact(true)
+}
+
+// finishCompaction stores the summary as the next row of the branch
+func (m Model) finishCompaction(msg CompactDoneMsg) (tea.Model, tea.Cmd) {
+	m.compacting = false
+	if m.statusPanel.status == Compacting {
+		m.statusPanel.status = AtEase
+	}
+
+	if msg.err != nil {
+		return m.showError(fmt.Sprintf("⚠️ Compacting failed: %v", msg.err))
+	}
+	// Messages added meanwhile would fall between the summary and its tail
+	if msg.conversationID != m.conversation.ID || msg.headID != m.branch.headID {
+		if msg.auto {
+			return m, nil
+		}
+		return m.showError("The conversation moved on while compacting, run /compact again")
+	}
+
+	m.messages = append(m.messages, types.Message{Role: summaryRole, Content: strings.TrimSpace(msg.summary)})
+	if err := m.saveLastMessage(); err != nil {
+		m.messages = m.messages[:len(m.messages)-1]
+		return m.showError("Error saving summary: " + err.Error())
+	}
+	note := fmt.Sprintf("📝 Compacted %d earlier messages", msg.compacted)
+	if msg.auto {
+		note += fmt.Sprintf(" (the conversation passed the %s budget for %s)", formatTokens(api.TokenBudget(m.ai.API, m.ai.Model)), m.ai.Model)
+	}
+	return m.showInfo(note)
+}
+
+// compactTranscript renders the turns to summarize as plain text, after the
+// summary they continue
+func compactTranscript(summary string, messages []types.Message, aiName string) string {
+	var b strings.Builder
+	if summary != "" {
+		b.WriteString("Summary of what came before:\n")
+		b.WriteString(summary)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Conversation:\n")
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			fmt.Fprintf(&b, "\nUser: %s\n", msg.Content)
+		case "assistant":
+			if msg.Content != "" {
+				fmt.Fprintf(&b, "\n%s: %s\n", aiName, msg.Content)
+			}
+			for _, call := range msg.FunctionCalls {
+				fmt.Fprintf(&b, "\n%s called %s %v\n", aiName, call.Name, call.Args)
+			}
+		case "tool":
+			fmt.Fprintf(&b, "\n%s returned: %s\n", msg.FunctionName, msg.Content)
+		}
+	}
+	return b.String()
+}
+
+// estimateTokens approximates the prompt size at four characters per token
+func estimateTokens(systemPrompt string, messages []types.Message) int {
+	chars := len(systemPrompt)
+	for _, msg := range messages {
+		chars += len(msg.Content)
+		for _, call := range msg.FunctionCalls {
+			chars += len(call.Name) + len(fmt.Sprint(call.Args))
+		}
+	}
+	return chars / 4
+}
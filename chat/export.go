@@ -0,0 +1,82 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/export"
+	"github.com/curator4/io-tui/types"
+)
+
+// exportCommand writes the active conversation, or every conversation, to a
+// file: /export [all] [md|json|html] [path]. The file lands in the current
+// directory unless a path is given.
+func (m Model) exportCommand(args []string) (tea.Model, tea.Cmd) {
+	all := len(args) > 0 && args[0] == "all"
+	if all {
+		args = args[1:]
+	}
+
+	format := "md"
+	if len(args) > 0 && slices.Contains(export.Formats, args[0]) {
+		format = args[0]
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		return m.showError("Usage: /export [all] [md|json|html] [path]")
+	}
+
+	var conversations []export.Conversation
+	var name string
+	if all {
+		loaded, err := export.LoadAll(m.database)
+		if err != nil {
+			return m.showError("Error exporting: " + err.Error())
+		}
+		if len(loaded) == 0 {
+			return m.showError("Nothing to export yet")
+		}
+		conversations = loaded
+		name = "io-tui-export-" + time.Now().Format("2006-01-02")
+	} else {
+		if m.conversation.ID == 0 {
+			return m.showError("No active conversation to export (use /resume, or /export all)")
+		}
+		conversation, err := export.Load(m.database, m.conversation.ID)
+		if err != nil {
+			return m.showError("Error exporting: " + err.Error())
+		}
+		conversations = []export.Conversation{conversation}
+		name = m.conversation.Name
+	}
+
+	path := export.FileName(name, format)
+	if len(args) == 1 {
+		path = args[0]
+		// A directory gets the suggested file name
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, export.FileName(name, format))
+		}
+	}
+
+	if err := export.WriteFile(path, format, conversations); err != nil {
+		return m.showError("Error exporting: " + err.Error())
+	}
+
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+	m.messages = append(m.messages, types.Message{
+		Role:    "system",
+		Content: fmt.Sprintf("📦 Exported %d conversation(s) to %s", len(conversations), path),
+	})
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	return m, nil
+}
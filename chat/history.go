@@ -0,0 +1,109 @@
+package chat
+
+import (
+	"fmt"
+
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/types"
+)
+
+// saveLastMessage writes the newest message, including its function calls
+// or result, to the active conversation as the next step of the branch
+func (m *Model) saveLastMessage() error {
+	msg := &m.messages[len(m.messages)-1]
+	row := db.Message{
+		Role:           msg.Role,
+		Content:        msg.Content,
+		FunctionCalls:  msg.FunctionCalls,
+		FunctionCallID: msg.FunctionCallID,
+		FunctionName:   msg.FunctionName,
+	}
+	if msg.Role == "assistant" {
+		row.API = m.ai.API
+		row.Model = m.ai.Model
+		row.PromptTokens = msg.Usage.PromptTokens
+		row.CompletionTokens = msg.Usage.CompletionTokens
+	}
+	var err error
+	msg.ID, err = m.store(row)
+	return err
+}
+
+// store saves one row after the branch head and makes it the new head
+func (m *Model) store(row db.Message) (int, error) {
+	// A new conversation starts a new tree
+	if m.branch.conversationID != m.conversation.ID {
+		m.branch = newBranchState(m.conversation.ID, nil, 0)
+	}
+	row.ConversationID = m.conversation.ID
+	row.ParentID = m.branch.headID
+	id, err := db.AddMessage(m.database, row)
+	if err != nil {
+		return 0, err
+	}
+	m.branch.add(storedRow(id, row))
+	return id, nil
+}
+
+// messagesFromDB rebuilds the conversation from stored rows
+func messagesFromDB(dbMessages []db.Message) []types.Message {
+	messages := []types.Message{}
+	for _, dbMsg := range dbMessages {
+		messages = append(messages, types.Message{
+			ID:             dbMsg.ID,
+			Role:           dbMsg.Role,
+			Content:        dbMsg.Content,
+			FunctionCalls:  dbMsg.FunctionCalls,
+			FunctionCallID: dbMsg.FunctionCallID,
+			FunctionName:   dbMsg.FunctionName,
+			Usage: types.Usage{
+				PromptTokens:     dbMsg.PromptTokens,
+				CompletionTokens: dbMsg.CompletionTokens,
+			},
+		})
+	}
+	return messages
+}
+
+// checkFunctionRows reports function results that can't be sent back to a
+// provider: results without a call id, or answering a call no message made
+func checkFunctionRows(rows []db.Message) error {
+	calls := make(map[string]bool)
+	for _, row := range rows {
+		for _, call := range row.FunctionCalls {
+			calls[call.ID] = true
+		}
+	}
+	for _, row := range rows {
+		if row.Role != "tool" {
+			continue
+		}
+		if row.FunctionCallID == "" || !calls[row.FunctionCallID] {
+			return fmt.Errorf("message %d is a function result without its call", row.ID)
+		}
+	}
+	return nil
+}
+
+// unansweredCalls lists the function calls of the last assistant turn that
+// have no result yet
+func unansweredCalls(messages []types.Message) []types.FunctionCall {
+	answered := make(map[string]bool)
+	for i := len(messages) - 1; i >= 0; i-- {
+		switch messages[i].Role {
+		case "tool":
+			answered[messages[i].FunctionCallID] = true
+		case "assistant":
+			var pending []types.FunctionCall
+			for _, call := range messages[i].FunctionCalls {
+				if !answered[call.ID] {
+					pending = append(pending, call)
+				}
+			}
+			return pending
+		case "user":
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/importer"
+	"github.com/curator4/io-tui/types"
+)
+
+// maxReportedSkips caps how many skipped conversations an import lists
+const maxReportedSkips = 10
+
+// ImportDoneMsg carries the outcome of an import running in the background
+type ImportDoneMsg struct {
+	message types.Message
+}
+
+// importCommand imports a ChatGPT, Anthropic or io-tui export into the
+// active AI, or the named one: /import <path> [ai name]
+func (m Model) importCommand(args []string) (tea.Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m.showError("Usage: /import <path> [ai name]")
+	}
+	path := args[0]
+
+	target := m.ai
+	if len(args) > 1 {
+		name := strings.Join(args[1:], " ")
+		ai, err := db.GetAIByName(m.database, name)
+		if err != nil {
+			return m.showError(fmt.Sprintf("Unknown AI: %s (see /list ais)", name))
+		}
+		target = ai
+	}
+
+	m.messages = append(m.messages, types.Message{
+		Role:    "system",
+		Content: fmt.Sprintf("📥 Importing %s into %s...", path, target.Name),
+	})
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+
+	database := m.database
+	return m, func() tea.Msg {
+		report, err := importer.Import(database, path, target.ID)
+		if err != nil {
+			return ImportDoneMsg{message: types.Message{
+				Role:    "system",
+				Content: fmt.Sprintf("❌ Import failed: %v", err),
+			}}
+		}
+		return ImportDoneMsg{message: types.Message{
+			Role:    "system",
+			Content: formatImportReport(report, target.Name),
+		}}
+	}
+}
+
+// formatImportReport summarizes an import, listing the first skipped entries
+func formatImportReport(report importer.Report, aiName string) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("📥 Imported %d conversation(s) with %d messages from a %s export into %s",
+		report.Imported, report.Messages, report.Format, aiName))
+	if report.Duplicates > 0 {
+		lines = append(lines, fmt.Sprintf("  %d already imported, left alone", report.Duplicates))
+	}
+	if report.SkippedMessages > 0 {
+		lines = append(lines, fmt.Sprintf("  %d message(s) left out (system prompts, attachments, empty)", report.SkippedMessages))
+	}
+	if len(report.Skipped) > 0 {
+		lines = append(lines, fmt.Sprintf("  %d conversation(s) skipped:", len(report.Skipped)))
+		for i, skipped := range report.Skipped {
+			if i == maxReportedSkips {
+				lines = append(lines, fmt.Sprintf("    ...and %d more", len(report.Skipped)-maxReportedSkips))
+				break
+			}
+			lines = append(lines, "    "+skipped)
+		}
+	}
+	if report.Imported > 0 {
+		lines = append(lines, "Find them with /resume or /search")
+	}
+	return strings.Join(lines, "\n")
+}
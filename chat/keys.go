@@ -0,0 +1,27 @@
+package chat
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/curator4/io-tui/config"
+)
+
+// keyMap holds the configurable chat mode bindings
+type keyMap struct {
+	Send key.Binding
+	Stop key.Binding
+	Quit key.Binding
+	PrevBranch key.Binding
+	NextBranch key.Binding
+	Select key.Binding
+}
+
+func newKeyMap(keys config.Keys) keyMap {
+	return keyMap{
+		Send: key.NewBinding(key.WithKeys(keys.Send...)),
+		Stop: key.NewBinding(key.WithKeys(keys.Stop...)),
+		Quit: key.NewBinding(key.WithKeys(keys.Quit...)),
+		PrevBranch: key.NewBinding(key.WithKeys(keys.PrevBranch...)),
+		NextBranch: key.NewBinding(key.WithKeys(keys.NextBranch...)),
+		Select: key.NewBinding(key.WithKeys(keys.Select...)),
+	}
+}
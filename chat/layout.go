@@ -0,0 +1,261 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+// Layouts, see computeLayout
+const (
+	layoutAuto    = "auto"
+	layoutStacked = "stacked"
+	layoutSide    = "side"
+)
+
+const (
+	// rounded border around everything, one cell per side
+	borderSize = 2
+	// the chat keeps at least this many rows, the art collapses first
+	minChatHeight = 8
+	// narrowest chat worth putting the panels beside
+	minSideChatWidth = 60
+	// width of the side column and of the info panel next to the art
+	minPanelWidth     = 28
+	statusPanelHeight = 3
+)
+
+// panels are the parts around the chat that /layout can hide
+type panels struct {
+	art  bool
+	info bool
+}
+
+// layout is where everything goes in the current terminal. Sizes are
+// inside the border.
+type layout struct {
+	width, height int
+	// side puts art, info and status in a column right of the chat,
+	// otherwise they are stacked above it
+	side bool
+	// bar replaces the stacked art and info with a one line summary
+	bar      bool
+	showArt  bool
+	showInfo bool
+	// headerHeight is the rows above the chat when stacked, 0 for none
+	headerHeight int
+	sideWidth    int
+	infoHeight   int
+	// size the art is drawn at when shown
+	artWidth   int
+	artHeight  int
+	chatWidth  int
+	chatHeight int
+}
+
+// computeLayout fits the panels and chat into a terminal. Wide terminals
+// (twice as many columns as rows, room for a 60 column chat) get the panels
+// beside the chat, others above it. Stacked art that would squeeze the chat
+// below minChatHeight rows collapses into a one line info bar. Art that
+// scales is sized to the space, up to two fifths of the rows stacked and a
+// third of the columns beside the chat.
+func computeLayout(width, height int, art artSize, inputHeight int, mode string, shown panels) layout {
+	l := layout{
+		width:  max(width-borderSize, 1),
+		height: max(height-borderSize, 1),
+	}
+	// the chat always has a separator above the input
+	chatRows := l.height - 1 - inputHeight
+
+	if !shown.art && !shown.info {
+		l.chatWidth, l.chatHeight = l.width, max(chatRows, 1)
+		return l
+	}
+
+	// Top to bottom beside the chat: art, info, status, with separators
+	sideFree := l.height - statusPanelHeight
+	sideArtWidth, sideArtHeight, sideArt := art.fit(l.width/3, sideFree-1, l.height/2)
+	sideWidth := minPanelWidth
+	if shown.art && sideArt {
+		sideWidth = max(sideArtWidth, minPanelWidth)
+	}
+	side := mode == layoutSide ||
+		(mode == layoutAuto && width >= 2*height && l.width-sideWidth-1 >= minSideChatWidth)
+	if side && l.width-sideWidth-1 >= minPanelWidth {
+		l.side = true
+		l.sideWidth = sideWidth
+		l.chatWidth = l.width - sideWidth - 1
+		l.chatHeight = max(chatRows, 1)
+
+		free := sideFree
+		l.showArt = shown.art && sideArt
+		if l.showArt {
+			l.artWidth, l.artHeight = sideArtWidth, sideArtHeight
+			free -= sideArtHeight + 1
+		}
+		l.infoHeight = free - 1
+		l.showInfo = shown.info && l.infoHeight >= 4
+		return l
+	}
+
+	// Stacked: art with info and status to its right, or the bar
+	l.chatWidth = l.width
+	artWidth, artHeight, fits := art.fit(l.width-1-minPanelWidth, chatRows-1-minChatHeight, l.height*2/5)
+	l.showArt = shown.art && fits
+	if l.showArt {
+		l.showInfo = shown.info
+		l.artWidth, l.artHeight = artWidth, artHeight
+		l.headerHeight = artHeight
+		l.infoHeight = artHeight - statusPanelHeight - 1
+	} else {
+		l.bar = true
+		l.headerHeight = 1
+	}
+	l.chatHeight = max(chatRows-l.headerHeight-1, 1)
+	return l
+}
+
+// applyLayout recomputes the layout and resizes the chat to match
+func (m *Model) applyLayout() {
+	m.art.load(m.database, m.ai.ID, m.ascii)
+	l := computeLayout(m.width, m.height, m.art.size(), m.textarea.Height(), m.layoutMode, m.panels)
+	resized := l.chatWidth != m.viewport.Width || l.chatHeight != m.viewport.Height
+	m.layout = l
+	if !resized {
+		return
+	}
+
+	m.viewport.Width = l.chatWidth
+	m.viewport.Height = l.chatHeight
+	m.textarea.SetWidth(l.chatWidth)
+	if len(m.messages) > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+}
+
+// header is drawn above the chat in the stacked layout
+func (m Model) header() string {
+	if m.layout.bar {
+		return m.infoBar(m.layout.width)
+	}
+
+	art := m.art.render(m.layout.artWidth, m.layout.artHeight)
+	panelWidth := m.layout.width - lipgloss.Width(art) - 1
+	right := []string{
+		m.infoPanel(panelWidth, m.layout.infoHeight),
+		m.horizontalSeparator(panelWidth - 2),
+		m.makeStatusPanel(),
+	}
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		art,
+		m.verticalSeparator(m.layout.headerHeight),
+		lipgloss.JoinVertical(lipgloss.Center, right...),
+	)
+}
+
+// sideColumn is drawn right of the chat in the side layout
+func (m Model) sideColumn() string {
+	width := m.layout.sideWidth
+	center := lipgloss.NewStyle().Width(width).Align(lipgloss.Center)
+
+	var parts []string
+	if m.layout.showArt {
+		parts = append(parts, center.Render(m.art.render(m.layout.artWidth, m.layout.artHeight)), m.horizontalSeparator(width))
+	}
+	if m.layout.showInfo {
+		parts = append(parts, m.infoPanel(width, m.layout.infoHeight), m.horizontalSeparator(width))
+	}
+	parts = append(parts, center.Render(m.makeStatusPanel()))
+	return lipgloss.NewStyle().
+		Height(m.layout.height).
+		MaxHeight(m.layout.height).
+		Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+// infoPanel is the info panel cut to size, blank when hidden. Spacing
+// lines go first when it doesn't fit.
+func (m Model) infoPanel(width, height int) string {
+	content := ""
+	if m.layout.showInfo {
+		content = m.makeInfoPanel()
+	}
+	if lipgloss.Height(content) > height {
+		var lines []string
+		for _, line := range strings.Split(content, "\n") {
+			if strings.TrimSpace(xansi.Strip(line)) != "" {
+				lines = append(lines, line)
+			}
+		}
+		content = strings.Join(lines, "\n")
+	}
+	return lipgloss.NewStyle().
+		Width(width).
+		Height(height).
+		MaxHeight(height).
+		Render(content)
+}
+
+// infoBar sums up the info panel in one line for short terminals
+func (m Model) infoBar(width int) string {
+	fields := []string{m.statusLine()}
+	if m.panels.info {
+		conversationName := "none"
+		if m.conversation.ID != 0 {
+			conversationName = m.conversation.Name
+		}
+		fields = append(fields,
+			m.valueStyle().Render(m.ai.Name),
+			m.labelStyle().Render(m.ai.API+"/"+m.ai.Model),
+			m.valueStyle().Render(conversationName),
+			m.labelStyle().Render(formatUsage(m.conversationUsage())),
+		)
+	}
+	return xansi.Truncate(strings.Join(fields, m.labelStyle().Render(" · ")), width, "…")
+}
+
+// layoutCommand picks the layout or toggles panels:
+// /layout [auto|stacked|side], /layout art|info
+func (m Model) layoutCommand(args []string) (tea.Model, tea.Cmd) {
+	if len(args) == 0 {
+		return m.showInfo(m.describeLayout())
+	}
+	if len(args) > 1 {
+		return m.showError("Usage: /layout [auto|stacked|side|art|info]")
+	}
+
+	switch args[0] {
+	case layoutAuto, layoutStacked, layoutSide:
+		m.layoutMode = args[0]
+	case "art":
+		m.panels.art = !m.panels.art
+	case "info":
+		m.panels.info = !m.panels.info
+	default:
+		return m.showError("Usage: /layout [auto|stacked|side|art|info]")
+	}
+	m.applyLayout()
+	return m.showInfo(m.describeLayout())
+}
+
+func (m Model) describeLayout() string {
+	current := layoutStacked
+	if m.layout.side {
+		current = layoutSide
+	}
+	if m.layoutMode == layoutAuto {
+		current += " (auto)"
+	}
+	shown := func(on bool) string {
+		if on {
+			return "shown"
+		}
+		return "hidden"
+	}
+	return fmt.Sprintf("🪟 Layout: %s, art %s, info %s\n/layout auto|stacked|side picks the layout, /layout art|info toggles a panel",
+		current, shown(m.panels.art), shown(m.panels.info))
+}
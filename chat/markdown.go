@@ -0,0 +1,214 @@
+package chat
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+// markdownCache renders assistant messages as markdown and remembers the
+// results, so a redraw only renders text that changed. A message that is
+// still streaming is rendered block by block: finished blocks come from the
+// cache and only the block being written is rendered for each chunk.
+// markdownCacheSize is how many rendered texts are kept before unused ones
+// are dropped
+const markdownCacheSize = 256
+
+type markdownCache struct {
+	renderer *glamour.TermRenderer
+	palette  string
+	width    int
+	rendered map[string]string
+	// rendered entries drawn since the last prune
+	used map[string]bool
+}
+
+func newMarkdownCache() *markdownCache {
+	return &markdownCache{
+		rendered: make(map[string]string),
+		used:     make(map[string]bool),
+	}
+}
+
+// prepare rebuilds the renderer when the palette or width changed
+func (c *markdownCache) prepare(palette []string, width int) {
+	key := strings.Join(palette, ",")
+	if c.renderer != nil && c.palette == key && c.width == width {
+		return
+	}
+	c.palette, c.width = key, width
+	c.rendered = make(map[string]string)
+	c.used = make(map[string]bool)
+
+	renderer, err := glamour.NewTermRenderer(
+		glamour.WithStyles(paletteStyle(palette)),
+		glamour.WithWordWrap(max(width, 20)),
+	)
+	if err != nil {
+		// Plain text it is
+		renderer = nil
+	}
+	c.renderer = renderer
+}
+
+// prune forgets entries that weren't drawn since the last prune, e.g. the
+// partial texts of a finished stream, once the cache has grown. Only full
+// renders prune, renders of part of the transcript (to measure scroll
+// offsets) leave it alone.
+func (c *markdownCache) prune() {
+	if len(c.rendered) > markdownCacheSize {
+		for text := range c.rendered {
+			if !c.used[text] {
+				delete(c.rendered, text)
+			}
+		}
+	}
+	c.used = make(map[string]bool)
+}
+
+// render returns the message as styled markdown, fallback styles it as
+// plain text when rendering fails
+func (c *markdownCache) render(text string, streaming bool, fallback lipgloss.Style) string {
+	if c.renderer == nil {
+		return fallback.Render(text)
+	}
+	if !streaming {
+		return c.block(text, fallback)
+	}
+
+	blocks := markdownBlocks(text)
+	rendered := make([]string, len(blocks))
+	for i, block := range blocks {
+		rendered[i] = c.block(block, fallback)
+	}
+	return strings.Join(rendered, "\n\n")
+}
+
+func (c *markdownCache) block(text string, fallback lipgloss.Style) string {
+	c.used[text] = true
+	if rendered, ok := c.rendered[text]; ok {
+		return rendered
+	}
+	rendered, err := c.renderer.Render(text)
+	if err != nil {
+		return fallback.Render(text)
+	}
+	rendered = trimBlankLines(rendered)
+	c.rendered[text] = rendered
+	return rendered
+}
+
+// trimBlankLines drops the padding lines glamour puts around a document,
+// they hold only styling codes and spaces
+func trimBlankLines(rendered string) string {
+	lines := strings.Split(rendered, "\n")
+	blank := func(line string) bool {
+		return strings.TrimSpace(xansi.Strip(line)) == ""
+	}
+	for len(lines) > 0 && blank(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && blank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownBlocks splits text at blank lines outside code fences, so blocks
+// that are complete render the same no matter what follows them
+func markdownBlocks(text string) []string {
+	var blocks []string
+	var current []string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if trimmed == "" && !inFence {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+// paletteStyle is glamour's dark style recolored with the AI's palette:
+// text in the assistant color, headings, links and code in the accents
+func paletteStyle(palette []string) ansi.StyleConfig {
+	color := func(i int) *string {
+		if i >= len(palette) {
+			return nil
+		}
+		c := palette[i]
+		return &c
+	}
+
+	style := styles.DarkStyleConfig
+	var noMargin uint
+
+	// The viewport already pads the transcript
+	style.Document = ansi.StyleBlock{
+		StylePrimitive: ansi.StylePrimitive{Color: color(1)},
+		Margin:         &noMargin,
+	}
+	style.BlockQuote.Color = color(5)
+	style.Heading.Color = color(3)
+	style.H1 = ansi.StyleBlock{StylePrimitive: ansi.StylePrimitive{Prefix: "# ", Color: color(0), Bold: style.Heading.Bold}}
+	style.Link.Color = color(6)
+	style.LinkText.Color = color(6)
+	style.Code.Color = color(4)
+	style.CodeBlock.Margin = &noMargin
+
+	chroma := *styles.DarkStyleConfig.CodeBlock.Chroma
+	chroma.Text.Color = color(5)
+	chroma.Keyword.Color = color(0)
+	chroma.KeywordReserved.Color = color(7)
+	chroma.KeywordType.Color = color(6)
+	chroma.NameFunction.Color = color(3)
+	chroma.NameBuiltin.Color = color(3)
+	chroma.LiteralString.Color = color(4)
+	chroma.LiteralNumber.Color = color(6)
+	style.CodeBlock.Chroma = &chroma
+	return style
+}
+
+// codeBlocks returns the contents of the fenced code blocks in text, an
+// unclosed fence (a reply still streaming) runs to the end
+func codeBlocks(text string) []string {
+	var blocks []string
+	var current []string
+	fence := ""
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				// The fence closes on a run of the same marks at least as long
+				fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+				current = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			fence = ""
+			continue
+		}
+		current = append(current, line)
+	}
+	if fence != "" && len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
@@ -0,0 +1,182 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/tools"
+)
+
+// maxPromptMemories caps how many memories ride along with each request,
+// the most relevant ones win once an AI remembers more than that
+const maxPromptMemories = 20
+
+// rememberInput stores "#text" as a memory of the active AI
+func (m Model) rememberInput(input string) (tea.Model, tea.Cmd) {
+	content := strings.TrimSpace(strings.TrimPrefix(input, "#"))
+	if content == "" {
+		return m.showError("Usage: #<something to remember>")
+	}
+	memory, err := db.AddMemory(m.database, m.ai.ID, content)
+	if err != nil {
+		return m.showError("Error saving memory: " + err.Error())
+	}
+	m.textarea.Reset()
+	return m.showInfo(fmt.Sprintf("🧠 %s will remember [%d]: %s", m.ai.Name, memory.ID, memory.Content))
+}
+
+// rememberFunc lets the remember tool save memories for the active AI
+func (m Model) rememberFunc() tools.RememberFunc {
+	database, aiID := m.database, m.ai.ID
+	return func(ctx context.Context, content string) error {
+		_, err := db.AddMemory(database, aiID, content)
+		return err
+	}
+}
+
+// memoryCommand manages the active AI's memories:
+// /memory [list], /memory delete <id>, /memory edit <id> <text>
+func (m Model) memoryCommand(args []string) (tea.Model, tea.Cmd) {
+	if len(args) == 0 || args[0] == "list" {
+		return m.listMemories()
+	}
+
+	switch args[0] {
+	case "delete":
+		if len(args) != 2 {
+			return m.showError("Usage: /memory delete <id>")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return m.showError("Usage: /memory delete <id> (see /memory list)")
+		}
+		if err := db.DeleteMemory(m.database, m.ai.ID, id); err != nil {
+			return m.showError("Error deleting memory: " + err.Error())
+		}
+		return m.showInfo(fmt.Sprintf("🧠 Forgot memory %d", id))
+	case "edit":
+		if len(args) < 3 {
+			return m.showError("Usage: /memory edit <id> <text>")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return m.showError("Usage: /memory edit <id> <text> (see /memory list)")
+		}
+		memory, err := db.UpdateMemory(m.database, m.ai.ID, id, strings.Join(args[2:], " "))
+		if err != nil {
+			return m.showError("Error updating memory: " + err.Error())
+		}
+		return m.showInfo(fmt.Sprintf("🧠 Memory %d is now: %s", memory.ID, memory.Content))
+	default:
+		return m.showError("Usage: /memory [list|delete <id>|edit <id> <text>]")
+	}
+}
+
+func (m Model) listMemories() (tea.Model, tea.Cmd) {
+	memories, err := db.ListMemories(m.database, m.ai.ID)
+	if err != nil {
+		return m.showError("Error loading memories: " + err.Error())
+	}
+	if len(memories) == 0 {
+		return m.showInfo(fmt.Sprintf("🧠 %s remembers nothing yet. Start a message with # to add a memory", m.ai.Name))
+	}
+
+	lines := []string{fmt.Sprintf("🧠 %s remembers:", m.ai.Name)}
+	for _, memory := range memories {
+		lines = append(lines, fmt.Sprintf("  [%d] %s", memory.ID, memory.Content))
+	}
+	return m.showInfo(strings.Join(lines, "\n"))
+}
+
+// promptWithMemories appends the active AI's relevant memories to its prompt.
+// Memories are best effort, a failed lookup sends the prompt unchanged.
+func (m Model) promptWithMemories(prompt string) string {
+	memories, err := db.ListMemories(m.database, m.ai.ID)
+	if err != nil || len(memories) == 0 {
+		return prompt
+	}
+	memories = relevantMemories(memories, m.lastUserInput(), maxPromptMemories)
+
+	var b strings.Builder
+	b.WriteString(prompt)
+	if prompt != "" {
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Things you remember from earlier conversations:")
+	for _, memory := range memories {
+		b.WriteString("\n- ")
+		b.WriteString(memory.Content)
+	}
+	return b.String()
+}
+
+// lastUserInput is the newest user message, what the request is about
+func (m Model) lastUserInput() string {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role == "user" {
+			return m.messages[i].Content
+		}
+	}
+	return ""
+}
+
+// relevantMemories keeps at most limit memories, preferring those sharing
+// words with the input and then the newest, in their original order
+func relevantMemories(memories []db.Memory, input string, limit int) []db.Memory {
+	if len(memories) <= limit {
+		return memories
+	}
+
+	inputWords := make(map[string]bool)
+	for _, word := range memoryWords(input) {
+		inputWords[word] = true
+	}
+	scores := make([]int, len(memories))
+	for i, memory := range memories {
+		for _, word := range memoryWords(memory.Content) {
+			if inputWords[word] {
+				scores[i]++
+			}
+		}
+	}
+
+	ranked := make([]int, len(memories))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		if scores[ranked[a]] != scores[ranked[b]] {
+			return scores[ranked[a]] > scores[ranked[b]]
+		}
+		return ranked[a] > ranked[b]
+	})
+	keep := ranked[:limit]
+	sort.Ints(keep)
+
+	relevant := make([]db.Memory, 0, limit)
+	for _, i := range keep {
+		relevant = append(relevant, memories[i])
+	}
+	return relevant
+}
+
+// memoryWords splits text into lowercase words, skipping short ones
+// like "the" or "is" that would match everything
+func memoryWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var words []string
+	for _, field := range fields {
+		if len([]rune(field)) > 3 {
+			words = append(words, field)
+		}
+	}
+	return words
+}
@@ -0,0 +1,107 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/api"
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/export"
+	"github.com/curator4/io-tui/persona"
+)
+
+// aiCommand shares characters as files:
+// /ai export <name> [path], /ai import <path> [new name]
+func (m Model) aiCommand(args []string) (tea.Model, tea.Cmd) {
+	if len(args) < 2 {
+		return m.showError("Usage: /ai export <name> [path] | /ai import <path> [new name]")
+	}
+	switch args[0] {
+	case "export":
+		if len(args) > 3 {
+			return m.showError("Usage: /ai export <name> [path]")
+		}
+		path := ""
+		if len(args) == 3 {
+			path = args[2]
+		}
+		return m.exportAI(args[1], path)
+	case "import":
+		if len(args) > 3 {
+			return m.showError("Usage: /ai import <path> [new name]")
+		}
+		name := ""
+		if len(args) == 3 {
+			name = args[2]
+		}
+		return m.importAI(args[1], name)
+	default:
+		return m.showError("Unknown ai command: " + args[0])
+	}
+}
+
+// exportAI writes an AI as a persona file, to the current directory unless
+// a path is given
+func (m Model) exportAI(name, path string) (tea.Model, tea.Cmd) {
+	ai, err := db.GetAIByName(m.database, name)
+	if err != nil {
+		return m.showError(fmt.Sprintf("Unknown AI: %s (see /list ais)", name))
+	}
+	p, err := persona.FromAI(ai)
+	if err != nil {
+		return m.showError("Error exporting AI: " + err.Error())
+	}
+	if p.Image, err = db.GetAIImage(m.database, ai.ID); err != nil {
+		return m.showError("Error exporting AI: " + err.Error())
+	}
+
+	if path == "" {
+		path = export.FileName(ai.Name, "json")
+	} else if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, export.FileName(ai.Name, "json"))
+	}
+	if err := persona.Save(path, p); err != nil {
+		return m.showError("Error exporting AI: " + err.Error())
+	}
+
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+	return m.showInfo(fmt.Sprintf("📦 Exported %s to %s", ai.Name, path))
+}
+
+// importAI creates an AI from a persona file or character card. Cards don't
+// name an api/model, those default like manifested characters do.
+func (m Model) importAI(path, name string) (tea.Model, tea.Cmd) {
+	p, err := persona.Load(path)
+	if err != nil {
+		return m.showError("Error importing AI: " + err.Error())
+	}
+	if name != "" {
+		p.Name = name
+	}
+
+	ai, err := p.AI()
+	if err != nil {
+		return m.showError("Error importing AI: " + err.Error())
+	}
+	if ai.API == "" {
+		ai.API, ai.Model = m.manifestTarget()
+	} else if ai.Model == "" {
+		ai.Model = api.AvailableAPIs[ai.API].DefaultModel
+	}
+
+	if _, err := db.GetAIByName(m.database, ai.Name); err == nil {
+		return m.showError(fmt.Sprintf("An AI named %s already exists, import it under another name: /ai import %s <new name>", ai.Name, path))
+	}
+	if err := db.CreateAIWithSettings(m.database, ai); err != nil {
+		return m.showError("Error importing AI: " + err.Error())
+	}
+	note := fmt.Sprintf("✨ Imported %s (%s - %s), switch to them with /set ai", ai.Name, ai.API, ai.Model)
+	if err := storeAIImage(m.database, ai.Name, p.Image); err != nil {
+		note += "\n" + imageWarning(err)
+	}
+	return m.showInfo(note)
+}
@@ -0,0 +1,86 @@
+package chat
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/types"
+)
+
+// Retries and edits never delete anything: the new response or message is
+// stored as a sibling of the old one, which stays reachable as an alternate
+// with the branch keys.
+
+// lastUserMessage returns the index of the newest stored user message, -1 if none
+func lastUserMessage(messages []types.Message) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" && messages[i].ID != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// retry generates a new response to the last user message: /retry
+func (m Model) retry() (tea.Model, tea.Cmd) {
+	if m.generating() {
+		m = m.interruptGeneration()
+	}
+	i := lastUserMessage(m.messages)
+	if i < 0 {
+		return m.showError("Nothing to retry yet")
+	}
+
+	// Back up to the user message, the new response branches off there
+	m.showBranch(m.messages[i].ID)
+	m.statusPanel.status = Processing
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+
+	ctx := m.startGeneration()
+	return m, m.callAI(ctx, "")
+}
+
+// startEdit loads the last user message into the textarea, sending it
+// stores the edit as an alternate and generates a new response: /edit
+func (m Model) startEdit() (tea.Model, tea.Cmd) {
+	i := lastUserMessage(m.messages)
+	if i < 0 {
+		return m.showError("No message to edit yet")
+	}
+
+	m.editingID = m.messages[i].ID
+	m.textarea.SetValue(m.messages[i].Content)
+	m.textarea.CursorEnd()
+	return m.showInfo("✏️ Editing your last message, send it to resend as an alternate (" + m.keys.Stop.Keys()[0] + " cancels)")
+}
+
+// cancelEdit leaves edit mode, dropping the edited text
+func (m Model) cancelEdit() (tea.Model, tea.Cmd) {
+	m.editingID = 0
+	m.textarea.Reset()
+	return m.showInfo("✏️ Edit cancelled")
+}
+
+// applyEdit cuts the conversation back to before the edited message so the
+// message about to be sent becomes its sibling
+func (m *Model) applyEdit() {
+	editingID := m.editingID
+	m.editingID = 0
+	if m.branch.conversationID != m.conversation.ID {
+		return
+	}
+	for _, row := range m.branch.tree {
+		if row.ID != editingID {
+			continue
+		}
+		if row.ParentID == 0 {
+			// The first message, the edit becomes another first message
+			m.messages = nil
+			m.branch.headID = 0
+		} else {
+			m.showBranch(row.ParentID)
+		}
+		return
+	}
+}
@@ -0,0 +1,88 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/db"
+)
+
+// searchLimit caps the hits /search lists
+const searchLimit = 100
+
+// List item for search results
+type searchItem struct {
+	hit db.SearchHit
+}
+
+func (s searchItem) FilterValue() string { return s.hit.ConversationName + " " + s.hit.Snippet }
+func (s searchItem) Title() string {
+	// snippets can span lines, the list shows one
+	return strings.Join(strings.Fields(s.hit.Snippet), " ")
+}
+func (s searchItem) Description() string {
+	return fmt.Sprintf("%s · %s · %s (%s)", s.hit.AIName, s.hit.ConversationName, s.hit.Role, s.hit.Created)
+}
+
+// searchMessages lists the messages of every conversation matching query
+func (m Model) searchMessages(query string) (tea.Model, tea.Cmd) {
+	hits, err := db.SearchMessages(m.database, query, searchLimit)
+	if err != nil {
+		return m.showError("Error searching messages: " + err.Error())
+	}
+	if len(hits) == 0 {
+		return m.showError(fmt.Sprintf("🔍 No messages match %q", query))
+	}
+
+	var items []list.Item
+	for _, hit := range hits {
+		items = append(items, searchItem{hit: hit})
+	}
+
+	m.list.SetItems(items)
+	m.list.Title = fmt.Sprintf("Search: %s (%d hits, Enter to open, Esc to close)", query, len(hits))
+	m.list.SetShowStatusBar(false)
+	m.list.SetFilteringEnabled(true)
+	m.list.SetShowHelp(true)
+	m.viewMode = listMode
+
+	return m, nil
+}
+
+// openSearchHit resumes the conversation of a hit, switching to its AI when
+// needed, and scrolls to the matched message
+func (m Model) openSearchHit(hit db.SearchHit) (tea.Model, tea.Cmd) {
+	if hit.AIID != 0 && hit.AIID != m.ai.ID {
+		newAI, err := db.SetActiveAI(m.database, hit.AIName)
+		if err != nil {
+			return m.showError("Error switching to AI '" + hit.AIName + "': " + err.Error())
+		}
+		m.useAI(newAI)
+	}
+
+	resumed, cmd := m.resumeConversationAt(hit.ConversationID, hit.MessageID)
+	m, ok := resumed.(Model)
+	if !ok {
+		return resumed, cmd
+	}
+	m.scrollToMessage(hit.MessageID)
+	return m, cmd
+}
+
+// scrollToMessage puts the message with the given database id at the top of
+// the viewport
+func (m *Model) scrollToMessage(messageID int) {
+	if m.viewport.Height <= 0 {
+		return
+	}
+	for i, msg := range m.messages {
+		if msg.ID != messageID {
+			continue
+		}
+		// The offset is the height of everything rendered before it
+		m.viewport.SetYOffset(m.messagesHeight(i))
+		return
+	}
+}
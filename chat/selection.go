@@ -0,0 +1,162 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// selection is the cursor of selection mode, where messages can be copied
+// without fighting the alt screen and mouse capture
+type selection struct {
+	active bool
+	// index into m.messages
+	index int
+	// outcome of the last copy, shown next to the cursor
+	note string
+}
+
+// startSelection puts the cursor on the newest message
+func (m Model) startSelection() (tea.Model, tea.Cmd) {
+	if len(m.messages) == 0 {
+		return m.showError("No messages to select yet")
+	}
+	m.selection = selection{active: true, index: len(m.messages) - 1}
+	m.refreshSelection()
+	return m, nil
+}
+
+// handleSelectionKey moves the cursor and copies:
+// up/down (k/j) move, y or enter copies the message, c or 1-9 a code block
+func (m Model) handleSelectionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Messages may have been replaced meanwhile (e.g. a tool switched the AI)
+	last := len(m.messages) - 1
+	if last < 0 {
+		m.selection = selection{}
+		m.refreshSelection()
+		return m, nil
+	}
+	m.selection.index = min(m.selection.index, last)
+
+	switch msg.String() {
+	case "esc", "q":
+		m.selection = selection{}
+	case "up", "k":
+		m.selection.index = max(m.selection.index-1, 0)
+		m.selection.note = ""
+	case "down", "j":
+		m.selection.index = min(m.selection.index+1, last)
+		m.selection.note = ""
+	case "home", "g":
+		m.selection.index = 0
+		m.selection.note = ""
+	case "end", "G":
+		m.selection.index = last
+		m.selection.note = ""
+	case "y", "enter":
+		m.selection.note = copyNote(m.messages[m.selection.index].Content, "message")
+	case "c":
+		m.selection.note = m.copyCodeBlock(1)
+	default:
+		if n, err := strconv.Atoi(msg.String()); err == nil && n >= 1 {
+			m.selection.note = m.copyCodeBlock(n)
+		}
+	}
+	m.refreshSelection()
+	return m, nil
+}
+
+// copyCodeBlock copies the nth code block of the selected message
+func (m Model) copyCodeBlock(n int) string {
+	blocks := codeBlocks(m.messages[m.selection.index].Content)
+	if n > len(blocks) {
+		if len(blocks) == 0 {
+			return "no code blocks here"
+		}
+		return fmt.Sprintf("only %d code blocks here", len(blocks))
+	}
+	return copyNote(blocks[n-1], fmt.Sprintf("code block %d", n))
+}
+
+func copyNote(text, what string) string {
+	if strings.TrimSpace(text) == "" {
+		return "nothing to copy"
+	}
+	if err := copyToClipboard(text); err != nil {
+		return "copy failed: " + err.Error()
+	}
+	return fmt.Sprintf("copied %s (%d characters)", what, len([]rune(text)))
+}
+
+// copyToClipboard sets the clipboard with an OSC 52 sequence, which the
+// terminal handles even over ssh and inside tmux or screen. Locally the
+// system clipboard tool is used as well, for terminals without OSC 52.
+func copyToClipboard(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, oscErr := seq.WriteTo(os.Stderr)
+
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		// The local clipboard belongs to the remote machine
+		return oscErr
+	}
+	if err := clipboard.WriteAll(text); err != nil && oscErr != nil {
+		return err
+	}
+	return nil
+}
+
+// selectionMarker is drawn above the selected message
+func (m Model) selectionMarker(i int, align lipgloss.Position) string {
+	if !m.selection.active || i != m.selection.index {
+		return ""
+	}
+	hint := "▸ y copy"
+	if n := len(codeBlocks(m.messages[i].Content)); n > 0 {
+		hint += fmt.Sprintf(" · c/1-%d code", n)
+	}
+	hint += " · ↑↓ move · esc done"
+	if m.selection.note != "" {
+		hint = "▸ " + m.selection.note
+	}
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.palette[6])).
+		Bold(true).
+		Align(align).
+		Width(m.viewport.Width).
+		Render(hint) + "\n"
+}
+
+// refreshSelection redraws and scrolls the selected message into view
+func (m *Model) refreshSelection() {
+	if m.viewport.Height <= 0 {
+		return
+	}
+	m.viewport.SetContent(m.formatMessages())
+	if !m.selection.active {
+		m.viewport.GotoBottom()
+		return
+	}
+
+	// Measure where the message starts and ends in the transcript
+	i := m.selection.index
+	top := m.messagesHeight(i)
+	bottom := m.messagesHeight(i + 1)
+
+	switch {
+	case top < m.viewport.YOffset:
+		m.viewport.SetYOffset(top)
+	case bottom > m.viewport.YOffset+m.viewport.Height:
+		m.viewport.SetYOffset(max(top, bottom-m.viewport.Height))
+	}
+}
@@ -0,0 +1,228 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/api"
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/tools"
+	"github.com/curator4/io-tui/types"
+)
+
+// maxToolRounds caps how many times one generation may call functions before
+// we stop feeding results back, so a confused model can't loop forever
+const maxToolRounds = 5
+
+// toolRegistry lists every tool the chat offers, bound to the current model.
+// New tools only need to be registered here.
+func (m Model) toolRegistry() *tools.Registry {
+	registry := tools.NewRegistry()
+	registry.Register(tools.NewManifest(m.createManifestedAI))
+	registry.Register(tools.NewRemember(m.rememberFunc()))
+	return registry
+}
+
+// enabledTools applies the active AI's enable/disable lists
+func (m Model) enabledTools() []tools.Tool {
+	return m.toolRegistry().Enabled(m.ai.EnabledTools, m.ai.DisabledTools)
+}
+
+// runFunctionCalls records the calls on the assistant turn that made them and
+// executes them in the background
+func (m Model) runFunctionCalls(calls []api.FunctionCall, usage api.Usage) (tea.Model, tea.Cmd) {
+	m.apiStatus = online
+
+	// Streaming leaves the text (possibly empty) in the last assistant message
+	if n := len(m.messages); n > 0 && m.messages[n-1].Role == "assistant" {
+		m.messages[n-1].FunctionCalls = calls
+	} else {
+		m.messages = append(m.messages, types.Message{Role: "assistant", FunctionCalls: calls})
+	}
+	m.messages[len(m.messages)-1].Usage = usage
+	m.recordUsage(usage)
+	if err := m.saveLastMessage(); err != nil {
+		m.messages = append(m.messages, types.Message{
+			Role:    "system",
+			Content: fmt.Sprintf("⚠️ Failed to save assistant message: %v", err),
+		})
+	}
+
+	m.toolRounds++
+	var activities []string
+	for _, call := range calls {
+		activity := "running " + call.Name
+		if tool, ok := m.toolRegistry().Get(call.Name); ok {
+			if announcer, ok := tool.(tools.Announcer); ok {
+				activity = announcer.Announce(call.Args)
+			}
+		}
+		activities = append(activities, activity)
+	}
+	m.statusPanel.status = UsingTools
+	m.statusPanel.activity = strings.Join(activities, ", ")
+
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	return m, m.executeFunctionCalls(m.generationCtx, calls)
+}
+
+// executeFunctionCalls runs every call and reports the results as tool messages
+func (m Model) executeFunctionCalls(ctx context.Context, calls []api.FunctionCall) tea.Cmd {
+	enabled := m.enabledTools()
+	generation := m.generation
+	return func() tea.Msg {
+		msg := FunctionResultsMsg{generation: generation}
+		for _, call := range calls {
+			result, err := executeFunctionCall(ctx, enabled, call)
+			content := result.Content
+			if err != nil {
+				// Errors go back to the model so it can explain or retry
+				content = fmt.Sprintf("error: %v", err)
+			} else if result.SwitchAI != "" {
+				msg.switchAI = result.SwitchAI
+			}
+			msg.results = append(msg.results, types.Message{
+				Role:           "tool",
+				Content:        content,
+				FunctionCallID: call.ID,
+				FunctionName:   call.Name,
+			})
+		}
+		return msg
+	}
+}
+
+// executeFunctionCall runs one call against the enabled tools
+func executeFunctionCall(ctx context.Context, enabled []tools.Tool, call api.FunctionCall) (tools.Result, error) {
+	for _, tool := range enabled {
+		if tool.Name() == call.Name {
+			return tool.Execute(ctx, call.Args)
+		}
+	}
+	return tools.Result{}, fmt.Errorf("unknown or disabled function %q", call.Name)
+}
+
+// continueWithResults stores the function results and asks the model to carry on
+func (m Model) continueWithResults(msg FunctionResultsMsg) (tea.Model, tea.Cmd) {
+	for _, result := range msg.results {
+		m.messages = append(m.messages, result)
+		if err := m.saveLastMessage(); err != nil {
+			m.messages = append(m.messages, types.Message{
+				Role:    "system",
+				Content: fmt.Sprintf("⚠️ Failed to save function result: %v", err),
+			})
+		}
+	}
+	if msg.switchAI != "" {
+		m.pendingSwitch = msg.switchAI
+	}
+
+	if m.toolRounds >= maxToolRounds {
+		m.messages = append(m.messages, types.Message{
+			Role:    "system",
+			Content: fmt.Sprintf("⚠️ Stopped after %d rounds of function calls", maxToolRounds),
+		})
+		m.statusPanel.status = AtEase
+		if m.viewport.Height > 0 {
+			m.viewport.SetContent(m.formatMessages())
+			m.viewport.GotoBottom()
+		}
+		return m, m.completeGeneration()
+	}
+
+	m.statusPanel.status = Processing
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	return m, m.callAI(m.generationCtx, "")
+}
+
+// toolsCommand lists the tools or changes which ones the active AI may use:
+// /tools, /tools enable|disable <name>, /tools only <name...>, /tools reset
+func (m Model) toolsCommand(args []string) (tea.Model, tea.Cmd) {
+	registry := m.toolRegistry()
+	if len(args) == 0 {
+		return m.listTools(registry)
+	}
+
+	enabled := m.ai.EnabledTools
+	disabled := m.ai.DisabledTools
+
+	switch args[0] {
+	case "enable", "disable", "only":
+		if len(args) < 2 {
+			return m.showError(fmt.Sprintf("Usage: /tools %s <tool name>", args[0]))
+		}
+		for _, name := range args[1:] {
+			if _, ok := registry.Get(name); !ok {
+				return m.showError(fmt.Sprintf("Unknown tool: %s (see /tools)", name))
+			}
+		}
+		switch args[0] {
+		case "enable":
+			disabled = without(disabled, args[1:]...)
+			// Only an allowlist has to name the tool explicitly
+			if len(enabled) > 0 {
+				enabled = append(without(enabled, args[1:]...), args[1:]...)
+			}
+		case "disable":
+			disabled = append(without(disabled, args[1:]...), args[1:]...)
+			enabled = without(enabled, args[1:]...)
+		case "only":
+			enabled = args[1:]
+			disabled = without(disabled, args[1:]...)
+		}
+	case "reset":
+		enabled, disabled = nil, nil
+	default:
+		return m.showError("Usage: /tools [enable|disable|only <name>|reset]")
+	}
+
+	updatedAI, err := db.UpdateActiveAITools(m.database, enabled, disabled)
+	if err != nil {
+		return m.showError("Error updating tools: " + err.Error())
+	}
+	m.ai = updatedAI
+	return m.listTools(registry)
+}
+
+// listTools shows every tool and whether the active AI may use it
+func (m Model) listTools(registry *tools.Registry) (tea.Model, tea.Cmd) {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("🔧 Tools for %s:", m.ai.Name))
+	for _, tool := range registry.All() {
+		mark := "✓"
+		if !tools.IsEnabled(tool.Name(), m.ai.EnabledTools, m.ai.DisabledTools) {
+			mark = "✗"
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s", mark, tool.Name()))
+	}
+
+	m.messages = append(m.messages, types.Message{
+		Role:    "system",
+		Content: strings.Join(lines, "\n"),
+	})
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// without returns names minus the given ones
+func without(names []string, remove ...string) []string {
+	var result []string
+	for _, name := range names {
+		if !slices.Contains(remove, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,138 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/curator4/io-tui/api"
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/types"
+)
+
+// usageDateLayout is the date format /usage accepts
+const usageDateLayout = "2006-01-02"
+
+// recordUsage adds a response's tokens to the session totals
+func (m *Model) recordUsage(usage types.Usage) {
+	m.sessionUsage = m.sessionUsage.Add(usage)
+	if cost, ok := api.EstimateCost(m.ai.API, m.ai.Model, usage); ok {
+		m.sessionCost += cost
+	}
+}
+
+// conversationUsage sums the tokens of the messages on screen
+func (m Model) conversationUsage() types.Usage {
+	var total types.Usage
+	for _, msg := range m.messages {
+		total = total.Add(msg.Usage)
+	}
+	return total
+}
+
+// formatUsage renders a usage compactly, e.g. "1.2k in / 340 out"
+func formatUsage(usage types.Usage) string {
+	return fmt.Sprintf("%s in / %s out", formatTokens(usage.PromptTokens), formatTokens(usage.CompletionTokens))
+}
+
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// showUsage reports tokens and estimated spend per model for a date range,
+// the last 30 days unless given: /usage [from] [to] (YYYY-MM-DD)
+func (m Model) showUsage(args []string) (tea.Model, tea.Cmd) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+
+	if len(args) > 0 {
+		parsed, err := time.Parse(usageDateLayout, args[0])
+		if err != nil {
+			return m.showError("Usage: /usage [from] [to] (dates as YYYY-MM-DD)")
+		}
+		from = parsed
+	}
+	if len(args) > 1 {
+		parsed, err := time.Parse(usageDateLayout, args[1])
+		if err != nil {
+			return m.showError("Usage: /usage [from] [to] (dates as YYYY-MM-DD)")
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		return m.showError("❌ The end date is before the start date")
+	}
+
+	totals, err := db.UsageBetween(m.database, from.Format(usageDateLayout), to.Format(usageDateLayout))
+	if err != nil {
+		return m.showError("Error loading usage: " + err.Error())
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("📊 Usage %s → %s:", from.Format(usageDateLayout), to.Format(usageDateLayout)))
+	if len(totals) == 0 {
+		lines = append(lines, "  no tokens recorded")
+	}
+	lines = append(lines, usageLines(totals)...)
+
+	// Totals for the active AI and conversation, whatever the range
+	if aiTotals, err := db.UsageByAI(m.database, m.ai.ID); err == nil && len(aiTotals) > 0 {
+		lines = append(lines, "", fmt.Sprintf("All time with %s:", m.ai.Name))
+		lines = append(lines, usageLines(aiTotals)...)
+	}
+	if m.conversation.ID != 0 {
+		if conversationTotals, err := db.UsageByConversation(m.database, m.conversation.ID); err == nil && len(conversationTotals) > 0 {
+			lines = append(lines, "", fmt.Sprintf("This conversation (%s):", m.conversation.Name))
+			lines = append(lines, usageLines(conversationTotals)...)
+		}
+	}
+
+	m.messages = append(m.messages, types.Message{
+		Role:    "system",
+		Content: strings.Join(lines, "\n"),
+	})
+	if m.viewport.Height > 0 {
+		m.viewport.SetContent(m.formatMessages())
+		m.viewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// usageLines renders one line per api/model and a total with the estimated cost
+func usageLines(totals []db.UsageTotal) []string {
+	var lines []string
+	var sum types.Usage
+	var cost float64
+	unpriced := false
+
+	for _, total := range totals {
+		usage := types.Usage{PromptTokens: total.PromptTokens, CompletionTokens: total.CompletionTokens}
+		sum = sum.Add(usage)
+
+		price := "no price"
+		if c, ok := api.EstimateCost(total.API, total.Model, usage); ok {
+			cost += c
+			price = fmt.Sprintf("$%.4f", c)
+		} else if total.API != "ollama" {
+			unpriced = true
+		}
+		lines = append(lines, fmt.Sprintf("  %s/%s: %s (%s)", total.API, total.Model, formatUsage(usage), price))
+	}
+
+	if len(totals) > 1 {
+		estimate := fmt.Sprintf("$%.4f", cost)
+		if unpriced {
+			estimate += " + unpriced models"
+		}
+		lines = append(lines, fmt.Sprintf("  total: %s (%s)", formatUsage(sum), estimate))
+	}
+	return lines
+}
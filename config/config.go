@@ -0,0 +1,268 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/curator4/io-tui/api"
+)
+
+// Config is read from $XDG_CONFIG_HOME/io-tui/config.toml. Everything is
+// optional, a missing file gives Default().
+type Config struct {
+	// Providers is keyed by api name (gemini, openai, anthropic, ollama)
+	Providers map[string]Provider `toml:"providers"`
+	Manifest  Manifest            `toml:"manifest"`
+	Keys      Keys                `toml:"keys"`
+	UI        UI                  `toml:"ui"`
+	Compact   Compact             `toml:"compact"`
+}
+
+// Provider overrides where an api lives and where its key comes from.
+// At most one key source may be set; without one the provider's usual
+// environment variables are used.
+type Provider struct {
+	BaseURL string `toml:"base_url"`
+	// APIKeyEnv names the environment variable holding the key
+	APIKeyEnv string `toml:"api_key_env"`
+	// APIKeyFile is read and trimmed, ~ expands to the home directory
+	APIKeyFile string `toml:"api_key_file"`
+	// APIKeyCommand runs through sh and its output is the key (e.g. "pass show openai")
+	APIKeyCommand string `toml:"api_key_command"`
+}
+
+// Manifest picks the api/model for manifested AIs, empty means the same
+// api/model as the AI doing the manifesting
+type Manifest struct {
+	API   string `toml:"api"`
+	Model string `toml:"model"`
+}
+
+// Keys binds chat actions to keys, in bubbletea notation ("enter", "esc", "ctrl+c")
+type Keys struct {
+	Send []string `toml:"send"`
+	// Stop interrupts a running response, quits when nothing is running
+	Stop []string `toml:"stop"`
+	Quit []string `toml:"quit"`
+	// PrevBranch and NextBranch switch between alternative branches
+	PrevBranch []string `toml:"prev_branch"`
+	NextBranch []string `toml:"next_branch"`
+	// Select moves a cursor over the messages to copy them
+	Select []string `toml:"select"`
+}
+
+// UI holds layout and color options
+type UI struct {
+	// Palette is used for AIs without their own palette, 8 hex colors
+	Palette []string `toml:"palette"`
+	// ShowHeader toggles the ascii art / info panel around the chat
+	ShowHeader bool `toml:"show_header"`
+	// Layout is auto, stacked (panels above the chat) or side (beside it)
+	Layout string `toml:"layout"`
+	// InputHeight is the number of lines of the message box
+	InputHeight int `toml:"input_height"`
+}
+
+// Compact controls how long conversations are summarized, see /compact
+type Compact struct {
+	// Auto compacts once a conversation exceeds the model's token budget
+	Auto bool `toml:"auto"`
+}
+
+// keyCommandTimeout bounds api_key_command, it may wait on a password prompt
+const keyCommandTimeout = 30 * time.Second
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Default is the configuration used when no file exists
+func Default() Config {
+	return Config{
+		Providers: map[string]Provider{},
+		Keys: Keys{
+			Send:       []string{"enter"},
+			Stop:       []string{"esc"},
+			Quit:       []string{"ctrl+c"},
+			PrevBranch: []string{"alt+left"},
+			NextBranch: []string{"alt+right"},
+			Select:     []string{"ctrl+s"},
+		},
+		UI: UI{
+			Palette: []string{
+				"#0061cd", "#ff79c6", "#1e40af", "#60a5fa",
+				"#fbbf24", "#e5e7eb", "#22d3ee", "#950056",
+			},
+			ShowHeader:  true,
+			Layout:      "auto",
+			InputHeight: 2,
+		},
+		Compact: Compact{
+			Auto: true,
+		},
+	}
+}
+
+// Path is $XDG_CONFIG_HOME/io-tui/config.toml, falling back to ~/.config
+func Path() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "io-tui", "config.toml"), nil
+}
+
+// Load reads and validates the config file at path, a missing file is not an error
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	meta, err := toml.DecodeFile(path, &cfg)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	problems := cfg.problems()
+	// Catch typos instead of silently ignoring them
+	for _, key := range meta.Undecoded() {
+		problems = append(problems, fmt.Sprintf("%s: unknown setting", key))
+	}
+	if len(problems) > 0 {
+		return Config{}, fmt.Errorf("%s: invalid config:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+	return cfg, nil
+}
+
+// problems lists everything wrong with the config, so Load reports it all at once
+func (c Config) problems() []string {
+	var problems []string
+
+	names := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		provider := c.Providers[name]
+		if _, ok := api.AvailableAPIs[name]; !ok {
+			problems = append(problems, fmt.Sprintf("providers.%s: unknown api", name))
+			continue
+		}
+		sources := 0
+		for _, source := range []string{provider.APIKeyEnv, provider.APIKeyFile, provider.APIKeyCommand} {
+			if source != "" {
+				sources++
+			}
+		}
+		if name == "ollama" && sources > 0 {
+			problems = append(problems, "providers.ollama: ollama takes no api key")
+		}
+		if sources > 1 {
+			problems = append(problems, fmt.Sprintf("providers.%s: set only one of api_key_env, api_key_file, api_key_command", name))
+		}
+	}
+
+	if c.Manifest.API != "" {
+		if _, ok := api.AvailableAPIs[c.Manifest.API]; !ok {
+			problems = append(problems, fmt.Sprintf("manifest.api: unknown api %q", c.Manifest.API))
+		}
+	} else if c.Manifest.Model != "" {
+		problems = append(problems, "manifest.model needs manifest.api")
+	}
+
+	bound := make(map[string]string)
+	actions := []struct {
+		action string
+		keys   []string
+	}{
+		{"send", c.Keys.Send},
+		{"stop", c.Keys.Stop},
+		{"quit", c.Keys.Quit},
+		{"prev_branch", c.Keys.PrevBranch},
+		{"next_branch", c.Keys.NextBranch},
+		{"select", c.Keys.Select},
+	}
+	for _, a := range actions {
+		action, keys := a.action, a.keys
+		if len(keys) == 0 {
+			problems = append(problems, fmt.Sprintf("keys.%s: needs at least one key", action))
+		}
+		for _, key := range keys {
+			if other, ok := bound[key]; ok && other != action {
+				problems = append(problems, fmt.Sprintf("keys: %q is bound to both %s and %s", key, other, action))
+			}
+			bound[key] = action
+		}
+	}
+
+	if len(c.UI.Palette) != 8 {
+		problems = append(problems, fmt.Sprintf("ui.palette: needs 8 colors, got %d", len(c.UI.Palette)))
+	}
+	for _, color := range c.UI.Palette {
+		if !hexColor.MatchString(color) {
+			problems = append(problems, fmt.Sprintf("ui.palette: %q is not a #rrggbb color", color))
+		}
+	}
+	switch c.UI.Layout {
+	case "auto", "stacked", "side":
+	default:
+		problems = append(problems, fmt.Sprintf("ui.layout: must be auto, stacked or side, got %q", c.UI.Layout))
+	}
+	if c.UI.InputHeight < 1 || c.UI.InputHeight > 10 {
+		problems = append(problems, fmt.Sprintf("ui.input_height: must be between 1 and 10, got %d", c.UI.InputHeight))
+	}
+
+	return problems
+}
+
+// APIKey resolves the key from the configured source, "" when none is configured
+func (p Provider) APIKey() (string, error) {
+	switch {
+	case p.APIKeyEnv != "":
+		key := os.Getenv(p.APIKeyEnv)
+		if key == "" {
+			return "", fmt.Errorf("%s is not set", p.APIKeyEnv)
+		}
+		return key, nil
+	case p.APIKeyFile != "":
+		data, err := os.ReadFile(expandHome(p.APIKeyFile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read api key file: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case p.APIKeyCommand != "":
+		ctx, cancel := context.WithTimeout(context.Background(), keyCommandTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "sh", "-c", p.APIKeyCommand).Output()
+		if err != nil {
+			return "", fmt.Errorf("api key command failed: %w", err)
+		}
+		// pass and friends print the secret on the first line
+		key, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		return strings.TrimSpace(key), nil
+	}
+	return "", nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 )
 
 
@@ -15,11 +17,16 @@ type AI struct {
 	PaletteJSON string
 	IsActive bool
 	Created string
+	// tool names; a non-empty EnabledTools allows only those tools
+	EnabledTools []string
+	DisabledTools []string
+	// sampling settings as stored by api.Params
+	ParamsJSON string
 }
 
 func GetAIByID(db *sql.DB, id int) (AI, error) {
 	row := db.QueryRow(`
-		SELECT id, name, system_prompt, api, model, ascii, palette_json, is_active, created
+		SELECT id, name, system_prompt, api, model, ascii, palette_json, is_active, created, enabled_tools, disabled_tools, params_json
 		FROM ais WHERE id = ?
 	`, id)
 	return scanAI(row)
@@ -27,7 +34,7 @@ func GetAIByID(db *sql.DB, id int) (AI, error) {
 
 func GetAIByName(db *sql.DB, name string) (AI, error) {
 	row := db.QueryRow(`
-		SELECT id, name, system_prompt, api, model, ascii, palette_json, is_active, created
+		SELECT id, name, system_prompt, api, model, ascii, palette_json, is_active, created, enabled_tools, disabled_tools, params_json
 		FROM ais WHERE name = ?
 	`, name)
 	return scanAI(row)
@@ -35,7 +42,7 @@ func GetAIByName(db *sql.DB, name string) (AI, error) {
 
 func ListAIs(db *sql.DB) ([]AI, error) {
 	rows, err := db.Query(`
-		SELECT id, name, system_prompt, api, model, ascii, palette_json, is_active, created
+		SELECT id, name, system_prompt, api, model, ascii, palette_json, is_active, created, enabled_tools, disabled_tools, params_json
 		FROM ais
 	`)
 	if err != nil {
@@ -56,7 +63,7 @@ func ListAIs(db *sql.DB) ([]AI, error) {
 
 func GetActiveAI(db *sql.DB) (AI, error) {
 	row := db.QueryRow(`
-		SELECT id, name, system_prompt, api, model, ascii, palette_json, is_active, created
+		SELECT id, name, system_prompt, api, model, ascii, palette_json, is_active, created, enabled_tools, disabled_tools, params_json
 		FROM ais WHERE is_active = true
 	`)
 	return scanAI(row)
@@ -88,6 +95,29 @@ func CreateAI(db *sql.DB, name, prompt, api, model, ascii, paletteJSON string, i
 	return err
 }
 
+// CreateAIWithSettings creates an inactive AI from every setting of ai,
+// including tool lists and params (used when importing personas)
+func CreateAIWithSettings(db *sql.DB, ai AI) error {
+	enabledJSON, err := encodeNames(ai.EnabledTools)
+	if err != nil {
+		return err
+	}
+	disabledJSON, err := encodeNames(ai.DisabledTools)
+	if err != nil {
+		return err
+	}
+	paramsJSON := ai.ParamsJSON
+	if paramsJSON == "" {
+		paramsJSON = "{}"
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO ais (name, system_prompt, api, model, ascii, palette_json, is_active, enabled_tools, disabled_tools, params_json)
+		VALUES (?, ?, ?, ?, ?, ?, false, ?, ?, ?)
+	`, ai.Name, ai.SystemPrompt, ai.API, ai.Model, ai.Ascii, ai.PaletteJSON, enabledJSON, disabledJSON, paramsJSON)
+	return err
+}
+
 func UpdateActiveAIAPI(db *sql.DB, apiName string, defaultModel string) (AI, error) {
 	// Update the active AI's API and set it to the default model
 	_, err := db.Exec(`
@@ -133,9 +163,76 @@ func UpdateActiveAIPrompt(db *sql.DB, prompt string) (AI, error) {
 	return GetActiveAI(db)
 }
 
+func UpdateActiveAITools(db *sql.DB, enabled, disabled []string) (AI, error) {
+	enabledJSON, err := encodeNames(enabled)
+	if err != nil {
+		return AI{}, err
+	}
+	disabledJSON, err := encodeNames(disabled)
+	if err != nil {
+		return AI{}, err
+	}
+
+	// Update the active AI's tool lists
+	_, err = db.Exec(`
+		UPDATE ais 
+		SET enabled_tools = ?, disabled_tools = ?
+		WHERE is_active = true
+	`, enabledJSON, disabledJSON)
+	if err != nil {
+		return AI{}, err
+	}
+	
+	// Return the updated active AI
+	return GetActiveAI(db)
+}
+
+func UpdateActiveAIParams(db *sql.DB, paramsJSON string) (AI, error) {
+	// Update the active AI's sampling settings
+	_, err := db.Exec(`
+		UPDATE ais 
+		SET params_json = ?
+		WHERE is_active = true
+	`, paramsJSON)
+	if err != nil {
+		return AI{}, err
+	}
+	
+	// Return the updated active AI
+	return GetActiveAI(db)
+}
+
 // Helper function to scan AI from database row
 func scanAI(scanner interface{ Scan(...interface{}) error }) (AI, error) {
 	var ai AI
-	err := scanner.Scan(&ai.ID, &ai.Name, &ai.SystemPrompt, &ai.API, &ai.Model, &ai.Ascii, &ai.PaletteJSON, &ai.IsActive, &ai.Created)
-	return ai, err
+	var enabledJSON, disabledJSON string
+	err := scanner.Scan(&ai.ID, &ai.Name, &ai.SystemPrompt, &ai.API, &ai.Model, &ai.Ascii, &ai.PaletteJSON, &ai.IsActive, &ai.Created, &enabledJSON, &disabledJSON, &ai.ParamsJSON)
+	if err != nil {
+		return ai, err
+	}
+	if ai.EnabledTools, err = decodeNames(enabledJSON); err != nil {
+		return ai, fmt.Errorf("invalid enabled_tools for %s: %w", ai.Name, err)
+	}
+	if ai.DisabledTools, err = decodeNames(disabledJSON); err != nil {
+		return ai, fmt.Errorf("invalid disabled_tools for %s: %w", ai.Name, err)
+	}
+	return ai, nil
+}
+
+// tool lists are stored as JSON arrays of names
+func encodeNames(names []string) (string, error) {
+	if len(names) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(names)
+	return string(data), err
+}
+
+func decodeNames(data string) ([]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var names []string
+	err := json.Unmarshal([]byte(data), &names)
+	return names, err
 }
@@ -3,12 +3,19 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	_ "modernc.org/sqlite"
 )
 
-func Init() (*sql.DB, error) {
-	db, err := sql.Open("sqlite", "data.db")
+// Init opens (creating if needed) the database at path and brings it up to date
+func Init(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -23,7 +30,12 @@ func Init() (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
-	// Run initial setup only on first run
+	// Create or upgrade the schema
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	// Seed the default ai only on first run
 	if isFirstRun(db) {
 		if err := initialSetup(db); err != nil {
 			return nil, fmt.Errorf("failed to run initial setup: %w", err)
@@ -47,11 +59,6 @@ func isFirstRun(db *sql.DB) bool {
 }
 
 func initialSetup(db *sql.DB) error {
-	// Create tables
-	if err := createTables(db); err != nil {
-		return fmt.Errorf("failed to create tables: %w", err)
-	}
-	
 	// Create seed data
 	if err := CreateAI(db, "Io", ioPrompt, defaultAPI, defaultModel, defaultASCII, defaultPaletteJSON, true); err != nil {
 		return fmt.Errorf("failed to create default ai: %w", err)
@@ -59,40 +66,3 @@ func initialSetup(db *sql.DB) error {
 	
 	return nil
 }
-
-
-func createTables(db *sql.DB) error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS ais (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE,
-		system_prompt TEXT,
-		api TEXT NOT NULL,
-		model TEXT NOT NULL,
-		ascii TEXT,
-		palette_json TEXT,
-		is_active BOOLEAN DEFAULT FALSE,
-		created DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	
-	CREATE TABLE IF NOT EXISTS conversations (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		ai_id INTEGER,
-		name TEXT NOT NULL,
-		is_active BOOLEAN DEFAULT FALSE,
-		created DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (ai_id) REFERENCES ais(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS messages (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		conversation_id INTEGER NOT NULL,
-		role TEXT NOT NULL,
-		content TEXT NOT NULL,
-		created DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (conversation_id) REFERENCES conversations(id)
-	);`
-
-	_, err := db.Exec(schema)
-	return err
-}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// SetAIImage stores the image an AI's ascii art is rendered from, replacing
+// any previous one
+func SetAIImage(db *sql.DB, aiID int, image []byte) error {
+	_, err := db.Exec(`
+		INSERT INTO ai_images (ai_id, image) VALUES (?, ?)
+		ON CONFLICT(ai_id) DO UPDATE SET image = excluded.image
+	`, aiID, image)
+	return err
+}
+
+// GetAIImage returns the AI's stored image, nil when it has none (AIs from
+// before images were kept, or created without one)
+func GetAIImage(db *sql.DB, aiID int) ([]byte, error) {
+	var image []byte
+	err := db.QueryRow("SELECT image FROM ai_images WHERE ai_id = ?", aiID).Scan(&image)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return image, err
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// TimestampLayout is how created columns are stored (UTC), the same text
+// CURRENT_TIMESTAMP produces so imported and live rows sort together
+const TimestampLayout = "2006-01-02 15:04:05"
+
+// ConversationExists reports whether the AI already has a conversation with
+// this name and creation time, used to skip repeated imports
+func ConversationExists(db *sql.DB, aiID int, name, created string) (bool, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM conversations
+		WHERE ai_id = ? AND name = ? AND created = ?
+	`, aiID, name, created).Scan(&count)
+	return count > 0, err
+}
+
+// ImportConversation stores a conversation with its messages, keeping their
+// timestamps (in TimestampLayout). A message whose parent is unknown or
+// comes after it is an error, nothing is stored then. The conversation is
+// not made active.
+func ImportConversation(db *sql.DB, aiID int, name, created string, messages []Message) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO conversations (ai_id, name, is_active, created)
+		VALUES (?, ?, false, ?)
+	`, aiID, name, created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create conversation: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	// Messages with ids keep their tree (ParentID refers to the ids given),
+	// messages without become one branch in order
+	newIDs := make(map[int]int)
+	previous := 0
+	for _, msg := range messages {
+		parentID := previous
+		if msg.ID != 0 {
+			if _, seen := newIDs[msg.ID]; seen {
+				return 0, fmt.Errorf("message id %d appears twice", msg.ID)
+			}
+			parentID = 0
+			if msg.ParentID != 0 {
+				newParentID, ok := newIDs[msg.ParentID]
+				if !ok {
+					return 0, fmt.Errorf("message %d follows message %d, which is missing or comes after it", msg.ID, msg.ParentID)
+				}
+				parentID = newParentID
+			}
+		}
+		row := msg
+		row.ConversationID = int(id)
+		row.ParentID = parentID
+		messageID, err := insertMessage(tx, row)
+		if err != nil {
+			return 0, fmt.Errorf("failed to save message: %w", err)
+		}
+		previous = messageID
+		if msg.ID != 0 {
+			newIDs[msg.ID] = previous
+		}
+	}
+
+	return int(id), tx.Commit()
+}
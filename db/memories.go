@@ -0,0 +1,98 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Memory is something an AI remembers across conversations
+type Memory struct {
+	ID      int
+	AIID    int
+	Content string
+	Created string
+}
+
+func AddMemory(db *sql.DB, aiID int, content string) (Memory, error) {
+	result, err := db.Exec(`
+		INSERT INTO memories (ai_id, content)
+		VALUES (?, ?)
+	`, aiID, content)
+	if err != nil {
+		return Memory{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Memory{}, err
+	}
+	return getMemory(db, aiID, int(id))
+}
+
+// ListMemories returns the memories of an AI, oldest first
+func ListMemories(db *sql.DB, aiID int) ([]Memory, error) {
+	rows, err := db.Query(`
+		SELECT id, ai_id, content, created
+		FROM memories
+		WHERE ai_id = ?
+		ORDER BY created ASC, id ASC
+	`, aiID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memories []Memory
+	for rows.Next() {
+		var memory Memory
+		if err := rows.Scan(&memory.ID, &memory.AIID, &memory.Content, &memory.Created); err != nil {
+			return nil, err
+		}
+		memories = append(memories, memory)
+	}
+	return memories, rows.Err()
+}
+
+// UpdateMemory rewrites one of the AI's memories
+func UpdateMemory(db *sql.DB, aiID, id int, content string) (Memory, error) {
+	result, err := db.Exec(`
+		UPDATE memories SET content = ?
+		WHERE id = ? AND ai_id = ?
+	`, content, id, aiID)
+	if err != nil {
+		return Memory{}, err
+	}
+	if err := requireRow(result, id); err != nil {
+		return Memory{}, err
+	}
+	return getMemory(db, aiID, id)
+}
+
+// DeleteMemory forgets one of the AI's memories
+func DeleteMemory(db *sql.DB, aiID, id int) error {
+	result, err := db.Exec("DELETE FROM memories WHERE id = ? AND ai_id = ?", id, aiID)
+	if err != nil {
+		return err
+	}
+	return requireRow(result, id)
+}
+
+func getMemory(db *sql.DB, aiID, id int) (Memory, error) {
+	var memory Memory
+	err := db.QueryRow(`
+		SELECT id, ai_id, content, created
+		FROM memories WHERE id = ? AND ai_id = ?
+	`, id, aiID).Scan(&memory.ID, &memory.AIID, &memory.Content, &memory.Created)
+	return memory, err
+}
+
+// requireRow turns an update that matched nothing into an error
+func requireRow(result sql.Result, id int) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("no memory with id %d", id)
+	}
+	return nil
+}
@@ -2,30 +2,71 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/curator4/io-tui/types"
 )
 
 type Message struct {
 	ID int
 	ConversationID int
+	// ParentID is the message this one follows, 0 for the first message.
+	// Messages sharing a parent are alternative branches.
+	ParentID int
 	Role string
 	Content string
 	Created string
+	// set on assistant messages, zero when the provider reported nothing
+	API string
+	Model string
+	PromptTokens int
+	CompletionTokens int
+	// FunctionCalls are the calls an assistant message made
+	FunctionCalls []types.FunctionCall
+	// FunctionCallID and FunctionName tie a "tool" message to the call it answers
+	FunctionCallID string
+	FunctionName string
 }
 
-func SaveMessage(db *sql.DB, conversation_id int, role string, content string) error {
-	_ , err := db.Exec(`
-		INSERT INTO messages (conversation_id, role, content)
-		VALUES (?, ?, ?)
-	`, conversation_id, role, content)
-	return err
+// AddMessage saves msg with its function calls or result and returns its id.
+// Created defaults to now, ID is ignored.
+func AddMessage(db *sql.DB, msg Message) (int, error) {
+	return insertMessage(db, msg)
+}
+
+// insertMessage is AddMessage for a database or a transaction
+func insertMessage(exec interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+}, msg Message) (int, error) {
+	calls := ""
+	if len(msg.FunctionCalls) > 0 {
+		encoded, err := json.Marshal(msg.FunctionCalls)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode function calls: %w", err)
+		}
+		calls = string(encoded)
+	}
+	result, err := exec.Exec(`
+		INSERT INTO messages (conversation_id, parent_id, role, content, created, api, model, prompt_tokens, completion_tokens,
+			function_calls, function_call_id, function_name)
+		VALUES (?, ?, ?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP), ?, ?, ?, ?, ?, ?, ?)
+	`, msg.ConversationID, nullableID(msg.ParentID), msg.Role, msg.Content, msg.Created, msg.API, msg.Model,
+		msg.PromptTokens, msg.CompletionTokens, calls, msg.FunctionCallID, msg.FunctionName)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
 }
 
+// LoadMessages returns every message of a conversation, all branches, oldest first
 func LoadMessages(db *sql.DB, conversation_id int) ([]Message, error) {
 	rows, err := db.Query(`
-		SELECT id, conversation_id, role, content, created
+		SELECT `+messageColumns+`
 		FROM messages
 		WHERE conversation_id = ?
-		ORDER BY created ASC
+		ORDER BY created ASC, id ASC
 	`, conversation_id)
 	if err != nil {
 		return nil, err
@@ -48,9 +89,29 @@ func DeleteMessagesByConversation(db *sql.DB, conversationID int) error {
 	return err
 }
 
+const messageColumns = "id, conversation_id, COALESCE(parent_id, 0), role, content, created, api, model, prompt_tokens, completion_tokens, function_calls, function_call_id, function_name"
+
 // Helper function to scan Message from database row
 func scanMessage(scanner interface{ Scan(...interface{}) error }) (Message, error) {
 	var msg Message
-	err := scanner.Scan(&msg.ID, &msg.ConversationID, &msg.Role, &msg.Content, &msg.Created)
-	return msg, err
+	var calls string
+	err := scanner.Scan(&msg.ID, &msg.ConversationID, &msg.ParentID, &msg.Role, &msg.Content, &msg.Created, &msg.API, &msg.Model, &msg.PromptTokens, &msg.CompletionTokens,
+		&calls, &msg.FunctionCallID, &msg.FunctionName)
+	if err != nil {
+		return msg, err
+	}
+	if calls != "" {
+		if err := json.Unmarshal([]byte(calls), &msg.FunctionCalls); err != nil {
+			return msg, fmt.Errorf("message %d has malformed function calls: %w", msg.ID, err)
+		}
+	}
+	return msg, nil
+}
+
+// nullableID stores 0 as NULL
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
 }
@@ -0,0 +1,230 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migration moves the schema forward by one version. Migrations run in order,
+// each inside its own transaction, and are never edited once released; a
+// schema change always gets a new migration at the end of the list.
+type migration struct {
+	version     int
+	description string
+	up          func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{1, "initial schema", createTables},
+	{2, "tool lists on ais", func(tx *sql.Tx) error {
+		if err := addColumn(tx, "ais", "enabled_tools", "TEXT NOT NULL DEFAULT '[]'"); err != nil {
+			return err
+		}
+		return addColumn(tx, "ais", "disabled_tools", "TEXT NOT NULL DEFAULT '[]'")
+	}},
+	{3, "generation params on ais", func(tx *sql.Tx) error {
+		return addColumn(tx, "ais", "params_json", "TEXT NOT NULL DEFAULT '{}'")
+	}},
+	{4, "token usage on messages", func(tx *sql.Tx) error {
+		columns := []struct{ name, definition string }{
+			{"api", "TEXT NOT NULL DEFAULT ''"},
+			{"model", "TEXT NOT NULL DEFAULT ''"},
+			{"prompt_tokens", "INTEGER NOT NULL DEFAULT 0"},
+			{"completion_tokens", "INTEGER NOT NULL DEFAULT 0"},
+		}
+		for _, column := range columns {
+			if err := addColumn(tx, "messages", column.name, column.definition); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{5, "full-text search on messages", createMessageSearch},
+	{6, "message tree", func(tx *sql.Tx) error {
+		if err := addColumn(tx, "messages", "parent_id", "INTEGER REFERENCES messages(id) ON DELETE CASCADE"); err != nil {
+			return err
+		}
+		// Existing conversations become a single branch, each message the
+		// child of the one before it
+		_, err := tx.Exec(`
+			UPDATE messages SET parent_id = (
+				SELECT p.id FROM messages p
+				WHERE p.conversation_id = messages.conversation_id
+					AND (p.created < messages.created OR (p.created = messages.created AND p.id < messages.id))
+				ORDER BY p.created DESC, p.id DESC
+				LIMIT 1
+			);
+			CREATE INDEX IF NOT EXISTS messages_parent ON messages(parent_id);`)
+		return err
+	}},
+	{7, "memories", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS memories (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				ai_id INTEGER NOT NULL REFERENCES ais(id) ON DELETE CASCADE,
+				content TEXT NOT NULL,
+				created DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS memories_ai ON memories(ai_id);`)
+		return err
+	}},
+	{8, "ai images", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS ai_images (
+				ai_id INTEGER PRIMARY KEY REFERENCES ais(id) ON DELETE CASCADE,
+				image BLOB NOT NULL
+			)`)
+		return err
+	}},
+	{9, "function calls on messages", func(tx *sql.Tx) error {
+		columns := []struct{ name, definition string }{
+			// JSON list of the calls an assistant message made
+			{"function_calls", "TEXT NOT NULL DEFAULT ''"},
+			// the call a tool message answers
+			{"function_call_id", "TEXT NOT NULL DEFAULT ''"},
+			{"function_name", "TEXT NOT NULL DEFAULT ''"},
+		}
+		for _, column := range columns {
+			if err := addColumn(tx, "messages", column.name, column.definition); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+}
+
+// SchemaVersion is the version this build migrates databases to
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate applies every migration newer than the database's version and
+// refuses databases written by a newer build
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			description TEXT NOT NULL,
+			applied DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_version: %w", err)
+	}
+
+	current, err := currentVersion(db)
+	if err != nil {
+		return err
+	}
+	if current > SchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d), please upgrade io-tui", current, SchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+	}
+	return nil
+}
+
+func currentVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version, description) VALUES (?, ?)", m.version, m.description); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func addColumn(tx *sql.Tx, table, column, definition string) error {
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// createMessageSearch adds an FTS5 index over messages.content that triggers
+// keep in sync, and indexes the messages already there
+func createMessageSearch(tx *sql.Tx) error {
+	schema := `
+	CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5(
+		content,
+		content='messages',
+		content_rowid='id'
+	);
+
+	CREATE TRIGGER IF NOT EXISTS messages_fts_insert AFTER INSERT ON messages BEGIN
+		INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS messages_fts_update AFTER UPDATE OF content ON messages BEGIN
+		INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+	END;
+
+	INSERT INTO messages_fts(messages_fts) VALUES ('rebuild');`
+
+	_, err := tx.Exec(schema)
+	return err
+}
+
+// createTables is the schema of the first release, later columns are added
+// by their own migrations
+func createTables(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS ais (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		system_prompt TEXT,
+		api TEXT NOT NULL,
+		model TEXT NOT NULL,
+		ascii TEXT,
+		palette_json TEXT,
+		is_active BOOLEAN DEFAULT FALSE,
+		created DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS conversations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		ai_id INTEGER,
+		name TEXT NOT NULL,
+		is_active BOOLEAN DEFAULT FALSE,
+		created DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (ai_id) REFERENCES ais(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		conversation_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (conversation_id) REFERENCES conversations(id)
+	);`
+
+	_, err := tx.Exec(schema)
+	return err
+}
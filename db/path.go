@@ -0,0 +1,87 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// legacyPath is where versions before the XDG layout kept the database,
+// relative to the directory io-tui was started from
+const legacyPath = "data.db"
+
+// DefaultPath is $XDG_DATA_HOME/io-tui/io.db, falling back to
+// ~/.local/share when XDG_DATA_HOME is unset
+func DefaultPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "io-tui", "io.db"), nil
+}
+
+// ResolvePath picks the database path: the --db flag, then IO_TUI_DB, then
+// DefaultPath. Only the default location adopts a legacy ./data.db.
+func ResolvePath(flagPath string) (string, error) {
+	if flagPath != "" {
+		return flagPath, nil
+	}
+	if envPath := os.Getenv("IO_TUI_DB"); envPath != "" {
+		return envPath, nil
+	}
+
+	path, err := DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	if err := migrateLegacy(path); err != nil {
+		return "", fmt.Errorf("failed to migrate %s: %w", legacyPath, err)
+	}
+	return path, nil
+}
+
+// migrateLegacy moves ./data.db to path, unless path already exists
+func migrateLegacy(path string) error {
+	if _, err := os.Stat(path); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if _, err := os.Stat(legacyPath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(legacyPath, path); err == nil {
+		return nil
+	}
+
+	// Rename fails across filesystems, copy instead and keep the original
+	return copyFile(legacyPath, path)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// SearchHit is a message matching a search, with where it was said
+type SearchHit struct {
+	MessageID        int
+	ConversationID   int
+	ConversationName string
+	AIID             int
+	AIName           string
+	Role             string
+	// Snippet is the matching part of the message, matches wrapped in [ ]
+	Snippet string
+	Created string
+}
+
+// SearchMessages finds user and assistant messages in every conversation
+// containing all words of query, best matches first
+func SearchMessages(db *sql.DB, query string, limit int) ([]SearchHit, error) {
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT m.id, m.conversation_id, c.name, COALESCE(a.id, 0), COALESCE(a.name, ''), m.role,
+			snippet(messages_fts, 0, '[', ']', '…', 12), m.created
+		FROM messages_fts
+		JOIN messages m ON m.id = messages_fts.rowid
+		JOIN conversations c ON c.id = m.conversation_id
+		LEFT JOIN ais a ON a.id = c.ai_id
+		WHERE messages_fts MATCH ? AND m.role IN ('user', 'assistant')
+		ORDER BY rank
+		LIMIT ?
+	`, match, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []SearchHit
+	for rows.Next() {
+		var hit SearchHit
+		err := rows.Scan(&hit.MessageID, &hit.ConversationID, &hit.ConversationName, &hit.AIID, &hit.AIName,
+			&hit.Role, &hit.Snippet, &hit.Created)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	return hits, rows.Err()
+}
+
+// ftsQuery quotes every word so user input can't trip over FTS5 syntax
+// (quotes, colons, AND/OR/NEAR...), the words are matched as prefixes
+func ftsQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
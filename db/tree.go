@@ -0,0 +1,84 @@
+package db
+
+// A conversation is a tree of messages linked by ParentID. These helpers
+// work on the rows LoadMessages returns (oldest first).
+
+// Branch returns the messages from the root down to leafID, nil when
+// leafID isn't among messages
+func Branch(messages []Message, leafID int) []Message {
+	byID := make(map[int]Message, len(messages))
+	for _, msg := range messages {
+		byID[msg.ID] = msg
+	}
+
+	var branch []Message
+	for id := leafID; id != 0; {
+		msg, ok := byID[id]
+		if !ok {
+			break
+		}
+		branch = append(branch, msg)
+		id = msg.ParentID
+	}
+	// Collected leaf first, flip to root first
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return branch
+}
+
+// LatestLeaf returns the newest leaf at or below id, the end of the branch
+// most recently written to from there. id 0 means the whole conversation.
+// The subtree is walked from id down, imported rows keep their original
+// timestamps so a child may sort before its parent.
+func LatestLeaf(messages []Message, id int) int {
+	// Position in LoadMessages order is age
+	position := make(map[int]int, len(messages))
+	children := make(map[int][]int)
+	for i, msg := range messages {
+		position[msg.ID] = i
+		children[msg.ParentID] = append(children[msg.ParentID], msg.ID)
+	}
+
+	latest, latestPosition := id, -1
+	visited := map[int]bool{id: true}
+	stack := []int{id}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		leaf := true
+		for _, child := range children[current] {
+			// A broken parent link must not loop forever
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			leaf = false
+			stack = append(stack, child)
+		}
+		if leaf && current != 0 && position[current] > latestPosition {
+			latest, latestPosition = current, position[current]
+		}
+	}
+	return latest
+}
+
+// Siblings returns the messages sharing the parent of the message with id,
+// itself included, oldest first
+func Siblings(messages []Message, id int) []Message {
+	parentID := -1
+	for _, msg := range messages {
+		if msg.ID == id {
+			parentID = msg.ParentID
+			break
+		}
+	}
+
+	var siblings []Message
+	for _, msg := range messages {
+		if msg.ParentID == parentID {
+			siblings = append(siblings, msg)
+		}
+	}
+	return siblings
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// UsageTotal sums the tokens of the messages produced by one api/model
+type UsageTotal struct {
+	API              string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Messages         int
+}
+
+// UsageByConversation totals a conversation's tokens per api/model
+func UsageByConversation(db *sql.DB, conversationID int) ([]UsageTotal, error) {
+	return usageTotals(db, "m.conversation_id = ?", conversationID)
+}
+
+// UsageByAI totals the tokens of all conversations with an AI per api/model
+func UsageByAI(db *sql.DB, aiID int) ([]UsageTotal, error) {
+	return usageTotals(db, "c.ai_id = ?", aiID)
+}
+
+// UsageBetween totals tokens per api/model for messages created between two
+// dates (YYYY-MM-DD, both inclusive)
+func UsageBetween(db *sql.DB, from, to string) ([]UsageTotal, error) {
+	return usageTotals(db, "date(m.created) BETWEEN ? AND ?", from, to)
+}
+
+func usageTotals(db *sql.DB, where string, args ...interface{}) ([]UsageTotal, error) {
+	rows, err := db.Query(`
+		SELECT m.api, m.model, SUM(m.prompt_tokens), SUM(m.completion_tokens), COUNT(*)
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE (m.prompt_tokens > 0 OR m.completion_tokens > 0) AND `+where+`
+		GROUP BY m.api, m.model
+		ORDER BY m.api, m.model
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []UsageTotal
+	for rows.Next() {
+		var total UsageTotal
+		if err := rows.Scan(&total.API, &total.Model, &total.PromptTokens, &total.CompletionTokens, &total.Messages); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
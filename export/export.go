@@ -0,0 +1,244 @@
+package export
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/types"
+)
+
+// FormatVersion is bumped when the JSON layout changes incompatibly
+const FormatVersion = 1
+
+// Formats lists the export formats by file extension
+var Formats = []string{"md", "json", "html"}
+
+// Document is the top level of a JSON export, one or many conversations
+type Document struct {
+	Version       int            `json:"version"`
+	Exported      string         `json:"exported"`
+	Conversations []Conversation `json:"conversations"`
+}
+
+// Conversation is a conversation with the AI it was held with
+type Conversation struct {
+	Name     string    `json:"name"`
+	Created  string    `json:"created"`
+	AI       AI        `json:"ai"`
+	Messages []Message `json:"messages"`
+}
+
+type AI struct {
+	Name         string `json:"name"`
+	SystemPrompt string `json:"system_prompt"`
+	API          string `json:"api"`
+	Model        string `json:"model"`
+}
+
+// Message is one stored row. Assistant messages carry the function calls
+// they made and "tool" messages the call they answer, so an import is
+// lossless.
+type Message struct {
+	// ID and ParentID keep the branches of the conversation, ids are only
+	// meaningful within one export
+	ID               int    `json:"id,omitempty"`
+	ParentID         int    `json:"parent_id,omitempty"`
+	Role             string `json:"role"`
+	Content          string `json:"content"`
+	Created          string `json:"created"`
+	API              string `json:"api,omitempty"`
+	Model            string `json:"model,omitempty"`
+	PromptTokens     int    `json:"prompt_tokens,omitempty"`
+	CompletionTokens int    `json:"completion_tokens,omitempty"`
+
+	FunctionCalls  []FunctionCall `json:"function_calls,omitempty"`
+	FunctionCallID string         `json:"function_call_id,omitempty"`
+	FunctionName   string         `json:"function_name,omitempty"`
+}
+
+// FunctionCall is a call an assistant message made
+type FunctionCall struct {
+	ID   string                 `json:"id"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// Load reads one conversation, its AI and messages from the database
+func Load(database *sql.DB, conversationID int) (Conversation, error) {
+	conversation, err := db.GetConversationByID(database, conversationID)
+	if err != nil {
+		return Conversation{}, fmt.Errorf("failed to load conversation: %w", err)
+	}
+	return load(database, conversation)
+}
+
+// LoadAll reads every conversation in the database, oldest first
+func LoadAll(database *sql.DB) ([]Conversation, error) {
+	conversations, err := db.ListConversations(database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+
+	var exported []Conversation
+	for i := len(conversations) - 1; i >= 0; i-- {
+		conversation, err := load(database, conversations[i])
+		if err != nil {
+			return nil, err
+		}
+		exported = append(exported, conversation)
+	}
+	return exported, nil
+}
+
+func load(database *sql.DB, conversation db.Conversation) (Conversation, error) {
+	exported := Conversation{
+		Name:    conversation.Name,
+		Created: conversation.Created,
+	}
+
+	// Conversations of deleted AIs are still exported, just without the AI
+	if ai, err := db.GetAIByID(database, conversation.AIID); err == nil {
+		exported.AI = AI{
+			Name:         ai.Name,
+			SystemPrompt: ai.SystemPrompt,
+			API:          ai.API,
+			Model:        ai.Model,
+		}
+	}
+
+	messages, err := db.LoadMessages(database, conversation.ID)
+	if err != nil {
+		return Conversation{}, fmt.Errorf("failed to load messages of %s: %w", conversation.Name, err)
+	}
+	for _, msg := range messages {
+		exported.Messages = append(exported.Messages, Message{
+			ID:               msg.ID,
+			ParentID:         msg.ParentID,
+			Role:             msg.Role,
+			Content:          msg.Content,
+			Created:          msg.Created,
+			API:              msg.API,
+			Model:            msg.Model,
+			PromptTokens:     msg.PromptTokens,
+			CompletionTokens: msg.CompletionTokens,
+			FunctionCalls:    exportCalls(msg.FunctionCalls),
+			FunctionCallID:   msg.FunctionCallID,
+			FunctionName:     msg.FunctionName,
+		})
+	}
+	return exported, nil
+}
+
+func exportCalls(calls []types.FunctionCall) []FunctionCall {
+	var exported []FunctionCall
+	for _, call := range calls {
+		exported = append(exported, FunctionCall{ID: call.ID, Name: call.Name, Args: call.Args})
+	}
+	return exported
+}
+
+// StoredCalls converts exported calls back for storage
+func StoredCalls(calls []FunctionCall) []types.FunctionCall {
+	var stored []types.FunctionCall
+	for _, call := range calls {
+		stored = append(stored, types.FunctionCall{ID: call.ID, Name: call.Name, Args: call.Args})
+	}
+	return stored
+}
+
+// Write renders conversations in format (md, json or html)
+func Write(w io.Writer, format string, conversations []Conversation) error {
+	switch format {
+	case "md":
+		return writeMarkdown(w, conversations)
+	case "json":
+		return writeJSON(w, conversations)
+	case "html":
+		return writeHTML(w, conversations)
+	default:
+		return fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// WriteFile renders conversations to path, creating its directory
+func WriteFile(path, format string, conversations []Conversation) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := Write(file, format, conversations); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// FileName suggests a file name for an export of name, e.g. "my-chat.md"
+func FileName(name, format string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			slug.WriteRune(r)
+			dash = false
+		} else if !dash && slug.Len() > 0 {
+			slug.WriteRune('-')
+			dash = true
+		}
+	}
+	base := strings.TrimSuffix(slug.String(), "-")
+	if base == "" {
+		base = "conversation"
+	}
+	return base + "." + format
+}
+
+func writeJSON(w io.Writer, conversations []Conversation) error {
+	document := Document{
+		Version:       FormatVersion,
+		Exported:      time.Now().UTC().Format(time.RFC3339),
+		Conversations: conversations,
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// latestBranch picks the branch most recently written to, markdown and
+// html show one line of conversation
+func latestBranch(messages []Message) []Message {
+	if len(messages) == 0 || messages[0].ID == 0 {
+		return messages
+	}
+	rows := make([]db.Message, len(messages))
+	byID := make(map[int]Message, len(messages))
+	for i, msg := range messages {
+		rows[i] = db.Message{ID: msg.ID, ParentID: msg.ParentID}
+		byID[msg.ID] = msg
+	}
+
+	var branch []Message
+	for _, row := range db.Branch(rows, db.LatestLeaf(rows, 0)) {
+		branch = append(branch, byID[row.ID])
+	}
+	return branch
+}
+
+// readable reports whether a message is part of the visible dialogue;
+// function calls and results only go into JSON exports
+func readable(msg Message) bool {
+	return msg.Role == "user" || msg.Role == "assistant"
+}
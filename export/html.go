@@ -0,0 +1,42 @@
+package export
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"readable":     readable,
+	"latestBranch": latestBranch,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{if eq (len .) 1}}{{(index . 0).Name}}{{else}}io-tui export{{end}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #1f2937; }
+.meta { color: #6b7280; font-size: 0.9rem; }
+.message { margin: 1rem 0; padding: 0.75rem 1rem; border-radius: 0.5rem; white-space: pre-wrap; }
+.user { background: #e0ecff; margin-left: 4rem; }
+.assistant { background: #fce7f3; margin-right: 4rem; }
+.speaker { font-weight: bold; display: block; margin-bottom: 0.25rem; }
+details { margin: 1rem 0; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+{{range .}}
+<section>
+<h1>{{.Name}}</h1>
+<p class="meta">{{if .AI.Name}}{{.AI.Name}} · {{.AI.API}}/{{.AI.Model}} · {{end}}started {{.Created}}</p>
+{{if .AI.SystemPrompt}}<details><summary>System prompt</summary>{{.AI.SystemPrompt}}</details>{{end}}
+{{$ai := .AI.Name}}{{range latestBranch .Messages}}{{if readable .}}<div class="message {{.Role}}"><span class="speaker">{{if eq .Role "user"}}You{{else if $ai}}{{$ai}}{{else}}Assistant{{end}} <span class="meta">{{.Created}}</span></span>{{.Content}}</div>
+{{end}}{{end}}</section>
+<hr>
+{{end}}
+</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, conversations []Conversation) error {
+	return htmlTemplate.Execute(w, conversations)
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func writeMarkdown(w io.Writer, conversations []Conversation) error {
+	var out strings.Builder
+	for i, conversation := range conversations {
+		if i > 0 {
+			out.WriteString("\n---\n\n")
+		}
+		fmt.Fprintf(&out, "# %s\n\n", conversation.Name)
+		if conversation.AI.Name != "" {
+			fmt.Fprintf(&out, "- **AI:** %s\n", conversation.AI.Name)
+			fmt.Fprintf(&out, "- **Model:** %s/%s\n", conversation.AI.API, conversation.AI.Model)
+		}
+		fmt.Fprintf(&out, "- **Started:** %s\n\n", conversation.Created)
+
+		if conversation.AI.SystemPrompt != "" {
+			out.WriteString("<details><summary>System prompt</summary>\n\n")
+			out.WriteString(conversation.AI.SystemPrompt)
+			out.WriteString("\n\n</details>\n\n")
+		}
+
+		for _, msg := range latestBranch(conversation.Messages) {
+			if !readable(msg) || strings.TrimSpace(msg.Content) == "" {
+				continue
+			}
+			speaker := "You"
+			if msg.Role == "assistant" {
+				speaker = conversation.AI.Name
+				if speaker == "" {
+					speaker = "Assistant"
+				}
+			}
+			fmt.Fprintf(&out, "### %s · %s\n\n%s\n\n", speaker, msg.Created, strings.TrimSpace(msg.Content))
+		}
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
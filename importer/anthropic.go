@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/curator4/io-tui/export"
+)
+
+// Claude's data export lists conversations with their messages in order,
+// "human" and "assistant" senders
+type anthropicConversation struct {
+	Name         string             `json:"name"`
+	CreatedAt    string             `json:"created_at"`
+	ChatMessages []anthropicMessage `json:"chat_messages"`
+}
+
+type anthropicMessage struct {
+	Sender    string `json:"sender"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"created_at"`
+	Content   []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+func parseAnthropic(entries []json.RawMessage) parsed {
+	var result parsed
+	for i, entry := range entries {
+		var conversation anthropicConversation
+		if err := json.Unmarshal(entry, &conversation); err != nil {
+			result.skipped = append(result.skipped, describe(i, "")+": malformed: "+err.Error())
+			continue
+		}
+
+		imported := export.Conversation{Name: strings.TrimSpace(conversation.Name)}
+		if imported.Name == "" {
+			imported.Name = "Imported conversation"
+		}
+		imported.Created, _ = parseTimestamp(conversation.CreatedAt)
+
+		for _, msg := range conversation.ChatMessages {
+			var role string
+			switch msg.Sender {
+			case "human", "user":
+				role = "user"
+			case "assistant":
+				role = "assistant"
+			}
+			text := anthropicText(msg)
+			if role == "" || text == "" {
+				result.skippedMessages++
+				continue
+			}
+			created, _ := parseTimestamp(msg.CreatedAt)
+			imported.Messages = append(imported.Messages, export.Message{
+				Role:    role,
+				Content: text,
+				Created: created,
+			})
+		}
+
+		if len(imported.Messages) == 0 {
+			result.skipped = append(result.skipped, describe(i, conversation.Name)+": no messages")
+			continue
+		}
+		fillTimestamps(&imported)
+		result.conversations = append(result.conversations, imported)
+	}
+	return result
+}
+
+// anthropicText prefers the text blocks of content, older exports only have text
+func anthropicText(msg anthropicMessage) string {
+	var parts []string
+	for _, block := range msg.Content {
+		if block.Type == "text" && strings.TrimSpace(block.Text) != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	if len(parts) == 0 {
+		return strings.TrimSpace(msg.Text)
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/curator4/io-tui/export"
+)
+
+// ChatGPT's conversations.json stores each conversation as a tree of nodes,
+// edits and regenerations branch off. current_node is the leaf that was on
+// screen, walking its parents gives the conversation as last seen.
+type chatGPTConversation struct {
+	Title       string                 `json:"title"`
+	CreateTime  *float64               `json:"create_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	Parent  string          `json:"parent"`
+	Message *chatGPTMessage `json:"message"`
+}
+
+type chatGPTMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime *float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+	} `json:"content"`
+}
+
+func parseChatGPT(entries []json.RawMessage) parsed {
+	var result parsed
+	for i, entry := range entries {
+		var conversation chatGPTConversation
+		if err := json.Unmarshal(entry, &conversation); err != nil {
+			result.skipped = append(result.skipped, describe(i, "")+": malformed: "+err.Error())
+			continue
+		}
+		if _, ok := conversation.Mapping[conversation.CurrentNode]; !ok {
+			result.skipped = append(result.skipped, describe(i, conversation.Title)+": malformed: current_node is missing")
+			continue
+		}
+
+		// Walk from the leaf to the root, then reverse
+		var path []*chatGPTMessage
+		visited := make(map[string]bool)
+		for id := conversation.CurrentNode; id != "" && !visited[id]; {
+			visited[id] = true
+			node, ok := conversation.Mapping[id]
+			if !ok {
+				break
+			}
+			if node.Message != nil {
+				path = append(path, node.Message)
+			}
+			id = node.Parent
+		}
+
+		imported := export.Conversation{Name: strings.TrimSpace(conversation.Title)}
+		if imported.Name == "" {
+			imported.Name = "Imported conversation"
+		}
+		imported.Created, _ = unixTimestamp(conversation.CreateTime)
+
+		for j := len(path) - 1; j >= 0; j-- {
+			msg := path[j]
+			role := msg.Author.Role
+			text := chatGPTText(msg)
+			if (role != "user" && role != "assistant") || text == "" {
+				// System prompts, tool output and image-only messages. Empty
+				// system/tool nodes (every tree has one at the root) aren't
+				// worth reporting.
+				if text != "" || role == "user" || role == "assistant" {
+					result.skippedMessages++
+				}
+				continue
+			}
+			created, _ := unixTimestamp(msg.CreateTime)
+			imported.Messages = append(imported.Messages, export.Message{
+				Role:    role,
+				Content: text,
+				Created: created,
+			})
+		}
+
+		if len(imported.Messages) == 0 {
+			result.skipped = append(result.skipped, describe(i, conversation.Title)+": no messages")
+			continue
+		}
+		fillTimestamps(&imported)
+		result.conversations = append(result.conversations, imported)
+	}
+	return result
+}
+
+// chatGPTText joins the text parts of a message, attachments are left out
+func chatGPTText(msg *chatGPTMessage) string {
+	if msg.Content.ContentType != "text" && msg.Content.ContentType != "multimodal_text" {
+		return ""
+	}
+	var parts []string
+	for _, raw := range msg.Content.Parts {
+		var part string
+		if json.Unmarshal(raw, &part) == nil && strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
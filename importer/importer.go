@@ -0,0 +1,191 @@
+package importer
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/export"
+)
+
+// Formats the importer recognizes
+const (
+	FormatNative    = "io-tui"
+	FormatChatGPT   = "chatgpt"
+	FormatAnthropic = "anthropic"
+)
+
+// Report sums up an import
+type Report struct {
+	Format   string
+	Imported int
+	Messages int
+	// Duplicates were imported before and left alone
+	Duplicates int
+	// Skipped lists conversations that couldn't be imported and why
+	Skipped []string
+	// SkippedMessages counts messages left out of imported conversations
+	// (system prompts, attachments, unknown roles, empty text)
+	SkippedMessages int
+}
+
+// parsed is what every format is turned into before it's stored
+type parsed struct {
+	conversations   []export.Conversation
+	skipped         []string
+	skippedMessages int
+}
+
+// Import reads an export file and stores its conversations under the AI,
+// keeping the original timestamps
+func Import(database *sql.DB, path string, aiID int) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	format, result, err := parse(data)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		Format:          format,
+		Skipped:         result.skipped,
+		SkippedMessages: result.skippedMessages,
+	}
+	for _, conversation := range result.conversations {
+		exists, err := db.ConversationExists(database, aiID, conversation.Name, conversation.Created)
+		if err != nil {
+			return report, err
+		}
+		if exists {
+			report.Duplicates++
+			continue
+		}
+
+		var messages []db.Message
+		for _, msg := range conversation.Messages {
+			messages = append(messages, db.Message{
+				ID:               msg.ID,
+				ParentID:         msg.ParentID,
+				Role:             msg.Role,
+				Content:          msg.Content,
+				Created:          msg.Created,
+				API:              msg.API,
+				Model:            msg.Model,
+				PromptTokens:     msg.PromptTokens,
+				CompletionTokens: msg.CompletionTokens,
+				FunctionCalls:    export.StoredCalls(msg.FunctionCalls),
+				FunctionCallID:   msg.FunctionCallID,
+				FunctionName:     msg.FunctionName,
+			})
+		}
+		if _, err := db.ImportConversation(database, aiID, conversation.Name, conversation.Created, messages); err != nil {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("%s: %v", conversation.Name, err))
+			continue
+		}
+		report.Imported++
+		report.Messages += len(messages)
+	}
+	return report, nil
+}
+
+// parse detects the format from the shape of the JSON
+func parse(data []byte) (string, parsed, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return "", parsed{}, fmt.Errorf("file is empty")
+	}
+
+	if trimmed[0] == '{' {
+		result, err := parseNative(trimmed)
+		return FormatNative, result, err
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(trimmed, &entries); err != nil {
+		return "", parsed{}, fmt.Errorf("not a JSON export: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", parsed{}, fmt.Errorf("export has no conversations")
+	}
+
+	// Look at the keys of the first entry that is an object
+	for _, entry := range entries {
+		var keys map[string]json.RawMessage
+		if json.Unmarshal(entry, &keys) != nil {
+			continue
+		}
+		if _, ok := keys["mapping"]; ok {
+			return FormatChatGPT, parseChatGPT(entries), nil
+		}
+		if _, ok := keys["chat_messages"]; ok {
+			return FormatAnthropic, parseAnthropic(entries), nil
+		}
+		break
+	}
+	return "", parsed{}, fmt.Errorf("unrecognized export, expected a ChatGPT or Anthropic conversations.json or an io-tui JSON export")
+}
+
+// timestamp formats a time the way the database stores them
+func timestamp(t time.Time) string {
+	return t.UTC().Format(db.TimestampLayout)
+}
+
+// parseTimestamp reads the timestamp formats found in exports, ok is false
+// for empty or unreadable values
+func parseTimestamp(value string) (string, bool) {
+	layouts := []string{time.RFC3339Nano, db.TimestampLayout, "2006-01-02T15:04:05", "2006-01-02 15:04:05.999999999-07:00"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return timestamp(t), true
+		}
+	}
+	return "", false
+}
+
+// unixTimestamp converts fractional epoch seconds, ok is false for nil
+func unixTimestamp(seconds *float64) (string, bool) {
+	if seconds == nil || *seconds <= 0 {
+		return "", false
+	}
+	whole := int64(*seconds)
+	nanos := int64((*seconds - float64(whole)) * 1e9)
+	return timestamp(time.Unix(whole, nanos)), true
+}
+
+// fillTimestamps gives messages without a time the time of the message
+// before them (or the conversation) and the conversation the time of its
+// first message when it has none
+func fillTimestamps(conversation *export.Conversation) {
+	if conversation.Created == "" {
+		conversation.Created = timestamp(time.Now())
+		for _, msg := range conversation.Messages {
+			if msg.Created != "" {
+				conversation.Created = msg.Created
+				break
+			}
+		}
+	}
+	last := conversation.Created
+	for i := range conversation.Messages {
+		if conversation.Messages[i].Created == "" {
+			conversation.Messages[i].Created = last
+		}
+		last = conversation.Messages[i].Created
+	}
+}
+
+// describe names an entry in the skipped list
+func describe(index int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("conversation %d", index+1)
+	}
+	return fmt.Sprintf("conversation %d (%s)", index+1, name)
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/curator4/io-tui/export"
+)
+
+// storedRoles are the message roles an io-tui export may contain
+var storedRoles = map[string]bool{"user": true, "assistant": true, "tool": true, "summary": true}
+
+// parseNative reads our own JSON export (see export.Document)
+func parseNative(data []byte) (parsed, error) {
+	var document export.Document
+	if err := json.Unmarshal(data, &document); err != nil {
+		return parsed{}, fmt.Errorf("malformed io-tui export: %w", err)
+	}
+	if document.Version == 0 {
+		return parsed{}, fmt.Errorf("not an io-tui export (no version)")
+	}
+	if document.Version > export.FormatVersion {
+		return parsed{}, fmt.Errorf("export format version %d is newer than this build supports (%d)", document.Version, export.FormatVersion)
+	}
+
+	var result parsed
+	for i, conversation := range document.Conversations {
+		// Replies to a left out message follow what it followed instead
+		dropped := make(map[int]int)
+		for _, msg := range conversation.Messages {
+			if !storedRoles[msg.Role] && msg.ID != 0 {
+				dropped[msg.ID] = msg.ParentID
+			}
+		}
+
+		var messages []export.Message
+		for _, msg := range conversation.Messages {
+			if !storedRoles[msg.Role] {
+				result.skippedMessages++
+				continue
+			}
+			msg.ParentID = keptParent(msg.ParentID, dropped)
+			msg.Created, _ = parseTimestamp(msg.Created)
+			messages = append(messages, msg)
+		}
+		if len(messages) == 0 {
+			result.skipped = append(result.skipped, describe(i, conversation.Name)+": no messages")
+			continue
+		}
+
+		conversation.Messages = parentsFirst(messages)
+		conversation.Created, _ = parseTimestamp(conversation.Created)
+		if conversation.Name == "" {
+			conversation.Name = "Imported conversation"
+		}
+		fillTimestamps(&conversation)
+		result.conversations = append(result.conversations, conversation)
+	}
+	return result, nil
+}
+
+// keptParent follows parentID past left out messages to the first one kept,
+// 0 when none is
+func keptParent(parentID int, dropped map[int]int) int {
+	// Dropped messages whose parents loop give up after visiting each once
+	for steps := 0; steps <= len(dropped); steps++ {
+		next, ok := dropped[parentID]
+		if !ok {
+			return parentID
+		}
+		parentID = next
+	}
+	return 0
+}
+
+// parentsFirst orders messages so each follows its parent, otherwise keeping
+// the export's order. Exports list messages oldest first, and imported rows
+// keep their original timestamps, so a reply may be listed before what it
+// answers. Messages whose parent is missing stay where they were for
+// ImportConversation to reject.
+func parentsFirst(messages []export.Message) []export.Message {
+	known := make(map[int]bool, len(messages))
+	for _, msg := range messages {
+		// Without ids, or with repeated ones, there is no tree to follow
+		if msg.ID == 0 || known[msg.ID] {
+			return messages
+		}
+		known[msg.ID] = true
+	}
+
+	children := make(map[int][]export.Message)
+	var roots []export.Message
+	for _, msg := range messages {
+		if msg.ParentID != 0 && known[msg.ParentID] {
+			children[msg.ParentID] = append(children[msg.ParentID], msg)
+		} else {
+			roots = append(roots, msg)
+		}
+	}
+
+	ordered := make([]export.Message, 0, len(messages))
+	placed := make(map[int]bool, len(messages))
+	var place func(msg export.Message)
+	place = func(msg export.Message) {
+		if placed[msg.ID] {
+			return
+		}
+		placed[msg.ID] = true
+		ordered = append(ordered, msg)
+		for _, child := range children[msg.ID] {
+			place(child)
+		}
+	}
+	for _, msg := range roots {
+		place(msg)
+	}
+	// Parent links that loop never reach a root
+	for _, msg := range messages {
+		if !placed[msg.ID] {
+			ordered = append(ordered, msg)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/curator4/io-tui/db"
+)
+
+// Messages replying to one with a role we don't store move up to its parent
+func TestImportNativeUnknownRole(t *testing.T) {
+	dir := t.TempDir()
+	database, err := db.Init(filepath.Join(dir, "io.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	ai, err := db.GetActiveAI(database)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "export.json")
+	document := `{"version": 1, "conversations": [{"name": "Tea", "created": "2025-01-02 10:00:00", "messages": [
+		{"id": 1, "role": "user", "content": "hi", "created": "2025-01-02 10:00:00"},
+		{"id": 2, "parent_id": 1, "role": "developer", "content": "dropped", "created": "2025-01-02 10:00:01"},
+		{"id": 3, "parent_id": 2, "role": "mystery", "content": "dropped too", "created": "2025-01-02 10:00:02"},
+		{"id": 4, "parent_id": 3, "role": "assistant", "content": "hello", "created": "2025-01-02 10:00:03"},
+		{"id": 5, "parent_id": 4, "role": "user", "content": "tea?", "created": "2025-01-02 10:00:04"}
+	]}]}`
+	if err := os.WriteFile(path, []byte(document), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Import(database, path, ai.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 1 || len(report.Skipped) != 0 {
+		t.Fatalf("report = %+v, want the conversation imported", report)
+	}
+	if report.Messages != 3 || report.SkippedMessages != 2 {
+		t.Errorf("messages = %d, skipped = %d, want 3 and 2", report.Messages, report.SkippedMessages)
+	}
+
+	conversations, err := db.ListConversationsByAI(database, ai.ID)
+	if err != nil || len(conversations) != 1 {
+		t.Fatalf("conversations = %+v, err = %v", conversations, err)
+	}
+	rows, err := db.LoadMessages(database, conversations[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("rows = %+v, want three", rows)
+	}
+	hi, hello, tea := rows[0], rows[1], rows[2]
+	if hello.Content != "hello" || hello.ParentID != hi.ID {
+		t.Errorf("hello = %+v, want it to follow %d", hello, hi.ID)
+	}
+	if tea.Content != "tea?" || tea.ParentID != hello.ID {
+		t.Errorf("tea = %+v, want it to follow %d", tea, hello.ID)
+	}
+}
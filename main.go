@@ -1,22 +1,51 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/curator4/io-tui/chat"
+	"github.com/curator4/io-tui/config"
 	"github.com/curator4/io-tui/db"
 )
 
 func main() {
-	database, err := db.Init()
+	dbFlag := flag.String("db", "", "path to the database (default $IO_TUI_DB, then $XDG_DATA_HOME/io-tui/io.db)")
+	configFlag := flag.String("config", "", "path to the config file (default $XDG_CONFIG_HOME/io-tui/config.toml)")
+	flag.Parse()
+
+	configPath := *configFlag
+	if configPath == "" {
+		path, err := config.Path()
+		if err != nil {
+			fmt.Printf("could not locate config: %v", err)
+			os.Exit(1)
+		}
+		configPath = path
+	}
+
+	// Report config mistakes before the TUI takes over the screen
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+
+	dbPath, err := db.ResolvePath(*dbFlag)
+	if err != nil {
+		fmt.Printf("could not locate database: %v", err)
+		os.Exit(1)
+	}
+
+	database, err := db.Init(dbPath)
 	if err != nil {
-		fmt.Printf("could not init database: %w", err)
+		fmt.Printf("could not init database: %v", err)
 		os.Exit(1)
 	}
 
-	p := tea.NewProgram(chat.InitialModel(database), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(chat.InitialModel(database, cfg), tea.WithAltScreen(), tea.WithMouseCellMotion())
 	if err := p.Start(); err != nil {
 		fmt.Printf("Error: %v", err)
 		os.Exit(1)
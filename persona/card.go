@@ -0,0 +1,143 @@
+package persona
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/curator4/io-tui/visual"
+)
+
+// Character cards are the format community chat frontends share characters
+// in: JSON with the character under "data" (spec chara_card_v2, v3 has the
+// same fields), or a PNG portrait carrying that JSON base64 encoded in a
+// tEXt chunk. v1 cards have the fields at the top level.
+type card struct {
+	Spec string   `json:"spec"`
+	Data cardData `json:"data"`
+	cardData
+}
+
+type cardData struct {
+	Name                    string `json:"name"`
+	Description             string `json:"description"`
+	Personality             string `json:"personality"`
+	Scenario                string `json:"scenario"`
+	MesExample              string `json:"mes_example"`
+	SystemPrompt            string `json:"system_prompt"`
+	PostHistoryInstructions string `json:"post_history_instructions"`
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// parseCard builds a persona from card JSON. A card's own system prompt is
+// used when it has one, otherwise the prompt is put together from the
+// description, personality and scenario.
+func parseCard(data []byte) (Persona, error) {
+	var c card
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Persona{}, fmt.Errorf("malformed character card: %w", err)
+	}
+	fields := c.Data
+	if c.Spec == "" {
+		fields = c.cardData
+	}
+	if strings.TrimSpace(fields.Name) == "" {
+		return Persona{}, fmt.Errorf("character card has no name")
+	}
+
+	var sections []string
+	if prompt := strings.TrimSpace(fields.SystemPrompt); prompt != "" {
+		sections = append(sections, prompt)
+	} else {
+		sections = append(sections, fmt.Sprintf("You are %s.", fields.Name))
+	}
+	if description := strings.TrimSpace(fields.Description); description != "" {
+		sections = append(sections, description)
+	}
+	if personality := strings.TrimSpace(fields.Personality); personality != "" {
+		sections = append(sections, "Personality: "+personality)
+	}
+	if scenario := strings.TrimSpace(fields.Scenario); scenario != "" {
+		sections = append(sections, "Scenario: "+scenario)
+	}
+	if examples := strings.TrimSpace(fields.MesExample); examples != "" {
+		sections = append(sections, "Example dialogue:\n"+examples)
+	}
+	if instructions := strings.TrimSpace(fields.PostHistoryInstructions); instructions != "" {
+		sections = append(sections, instructions)
+	}
+
+	// Cards refer to the character and user through macros
+	replacer := strings.NewReplacer("{{char}}", fields.Name, "{{user}}", "the user", "<BOT>", fields.Name, "<USER>", "the user")
+	return Persona{
+		Format:       Format,
+		Version:      Version,
+		Name:         strings.TrimSpace(fields.Name),
+		SystemPrompt: replacer.Replace(strings.Join(sections, "\n\n")),
+	}, nil
+}
+
+// loadPNGCard reads the card embedded in a PNG and renders the portrait
+// as the character's ASCII art and palette, keeping it as the image
+func loadPNGCard(path string, data []byte) (Persona, error) {
+	cardJSON, err := pngCardText(data)
+	if err != nil {
+		return Persona{}, err
+	}
+	persona, err := parseCard(cardJSON)
+	if err != nil {
+		return Persona{}, err
+	}
+
+	// A bad image only costs the art, the character is still usable
+	if palette, ascii, image, err := visual.GenerateFromImageFile(path); err == nil {
+		persona.Palette = palette
+		persona.Ascii = ascii
+		persona.Image = image
+	}
+	return persona, nil
+}
+
+// pngCardText finds the card tEXt chunk and decodes it, v3 cards carry
+// both and the v3 one wins
+func pngCardText(data []byte) ([]byte, error) {
+	chunks := make(map[string][]byte)
+	rest := data[len(pngSignature):]
+	for len(rest) >= 12 {
+		length := binary.BigEndian.Uint32(rest[:4])
+		chunkType := string(rest[4:8])
+		if uint64(len(rest)) < 12+uint64(length) {
+			return nil, fmt.Errorf("truncated PNG")
+		}
+		chunk := rest[8 : 8+length]
+		rest = rest[12+length:]
+
+		if chunkType == "IEND" {
+			break
+		}
+		if chunkType != "tEXt" {
+			continue
+		}
+		keyword, text, ok := bytes.Cut(chunk, []byte{0})
+		if ok {
+			chunks[string(keyword)] = text
+		}
+	}
+
+	for _, keyword := range []string{"ccv3", "chara"} {
+		encoded, ok := chunks[keyword]
+		if !ok {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+		if err != nil {
+			return nil, fmt.Errorf("malformed %s chunk: %w", keyword, err)
+		}
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("PNG has no character card embedded")
+}
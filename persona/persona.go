@@ -0,0 +1,151 @@
+package persona
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/curator4/io-tui/api"
+	"github.com/curator4/io-tui/db"
+	"github.com/curator4/io-tui/visual"
+)
+
+// Format and Version identify an io-tui persona file
+const (
+	Format  = "io-tui-persona"
+	Version = 1
+)
+
+// Persona is everything that makes up an AI, as written by /ai export.
+// Sharing the file gives everyone the same character without manifesting
+// it again.
+type Persona struct {
+	Format        string     `json:"format"`
+	Version       int        `json:"version"`
+	Name          string     `json:"name"`
+	SystemPrompt  string     `json:"system_prompt"`
+	API           string     `json:"api,omitempty"`
+	Model         string     `json:"model,omitempty"`
+	Params        api.Params `json:"params"`
+	EnabledTools  []string   `json:"enabled_tools,omitempty"`
+	DisabledTools []string   `json:"disabled_tools,omitempty"`
+	Ascii         string     `json:"ascii,omitempty"`
+	Palette       []string   `json:"palette,omitempty"`
+	// Image is the PNG thumbnail the ascii art is rendered from, base64 in
+	// the file. Older files and AIs without one only have the ascii.
+	Image []byte `json:"image,omitempty"`
+}
+
+// FromAI captures an AI as a persona
+func FromAI(ai db.AI) (Persona, error) {
+	params, err := api.DecodeParams(ai.ParamsJSON)
+	if err != nil {
+		return Persona{}, err
+	}
+	persona := Persona{
+		Format:        Format,
+		Version:       Version,
+		Name:          ai.Name,
+		SystemPrompt:  ai.SystemPrompt,
+		API:           ai.API,
+		Model:         ai.Model,
+		Params:        params,
+		EnabledTools:  ai.EnabledTools,
+		DisabledTools: ai.DisabledTools,
+		Ascii:         ai.Ascii,
+	}
+	if ai.PaletteJSON != "" {
+		if persona.Palette, err = visual.ParsePaletteFromDB(ai.PaletteJSON); err != nil {
+			return Persona{}, err
+		}
+	}
+	return persona, nil
+}
+
+// AI turns the persona back into an AI ready for db.CreateAIWithSettings.
+// API and Model are empty for character cards, the caller picks them.
+func (p Persona) AI() (db.AI, error) {
+	if strings.TrimSpace(p.Name) == "" {
+		return db.AI{}, fmt.Errorf("persona has no name")
+	}
+	if p.API != "" {
+		if _, ok := api.AvailableAPIs[p.API]; !ok {
+			return db.AI{}, fmt.Errorf("persona uses unknown api %q", p.API)
+		}
+	}
+
+	paramsJSON, err := p.Params.Encode()
+	if err != nil {
+		return db.AI{}, err
+	}
+	ai := db.AI{
+		Name:          p.Name,
+		SystemPrompt:  p.SystemPrompt,
+		API:           p.API,
+		Model:         p.Model,
+		Ascii:         p.Ascii,
+		EnabledTools:  p.EnabledTools,
+		DisabledTools: p.DisabledTools,
+		ParamsJSON:    paramsJSON,
+	}
+	if len(p.Palette) > 0 {
+		if ai.PaletteJSON, err = visual.FormatPaletteForDB(p.Palette); err != nil {
+			return db.AI{}, err
+		}
+	}
+	return ai, nil
+}
+
+// Save writes the persona as indented JSON
+func Save(path string, p Persona) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode persona: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load reads a persona file, a character card v2 JSON file or a PNG with a
+// card embedded in it
+func Load(path string) (Persona, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Persona{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if bytes.HasPrefix(data, pngSignature) {
+		return loadPNGCard(path, data)
+	}
+
+	var header struct {
+		Format      string  `json:"format"`
+		Version     int     `json:"version"`
+		Spec        string  `json:"spec"`
+		Description *string `json:"description"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Persona{}, fmt.Errorf("%s is not JSON or a PNG card: %w", path, err)
+	}
+
+	switch {
+	case header.Format == Format:
+		if header.Version > Version {
+			return Persona{}, fmt.Errorf("persona format version %d is newer than this build supports (%d)", header.Version, Version)
+		}
+		var persona Persona
+		if err := json.Unmarshal(data, &persona); err != nil {
+			return Persona{}, fmt.Errorf("malformed persona: %w", err)
+		}
+		return persona, nil
+	case header.Spec != "" || header.Description != nil:
+		// v2/v3 cards name their spec, v1 cards are just the fields
+		return parseCard(data)
+	default:
+		return Persona{}, fmt.Errorf("%s is neither an io-tui persona nor a character card", path)
+	}
+}
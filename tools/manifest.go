@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+)
+
+// ManifestFunc creates a new character from a name, an image and a
+// description. warning reports something that went wrong without stopping it.
+type ManifestFunc func(ctx context.Context, name, imageURL, description string) (warning string, err error)
+
+// Manifest lets the model create a character when the user asks for one
+type Manifest struct {
+	create ManifestFunc
+}
+
+func NewManifest(create ManifestFunc) *Manifest {
+	return &Manifest{create: create}
+}
+
+func (t *Manifest) Name() string {
+	return "manifest_character"
+}
+
+func (t *Manifest) Description() string {
+	return "Call this function ONLY when the user mentions 'manifest' with a character name AND provides an image URL. If no image URL is provided, do NOT call this function - instead ask the user to provide an image URL."
+}
+
+func (t *Manifest) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Just the character name (e.g., 'L', 'Sherlock Holmes', 'Tony Stark')",
+			},
+			"image_url": map[string]interface{}{
+				"type":        "string",
+				"description": "The EXACT image URL provided by the user in their message. Must be a direct link to a PNG or JPG file. NEVER use placeholders or make up URLs.",
+			},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"description": "You must provide a detailed description of the character's personality, traits, background, and how they should behave. Include specific details about their speaking style, mannerisms, and key characteristics. This will be used to automatically generate their system prompt.",
+			},
+		},
+		"required": []string{"name", "image_url", "description"},
+	}
+}
+
+func (t *Manifest) Announce(args map[string]interface{}) string {
+	name, _ := args["name"].(string)
+	return "manifesting " + name
+}
+
+func (t *Manifest) Execute(ctx context.Context, args map[string]interface{}) (Result, error) {
+	name, _ := args["name"].(string)
+	imageURL, _ := args["image_url"].(string)
+	description, _ := args["description"].(string)
+	if name == "" || imageURL == "" {
+		return Result{}, fmt.Errorf("name and image_url are required")
+	}
+
+	warning, err := t.create(ctx, name, imageURL, description)
+	if err != nil {
+		return Result{}, err
+	}
+	content := fmt.Sprintf("%s was manifested and will take over the chat after this reply", name)
+	if warning != "" {
+		content += "\n" + warning
+	}
+	return Result{
+		Content:  content,
+		SwitchAI: name,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RememberFunc stores a memory for the active AI
+type RememberFunc func(ctx context.Context, content string) error
+
+// Remember lets the model keep facts about the user across conversations
+type Remember struct {
+	remember RememberFunc
+}
+
+func NewRemember(remember RememberFunc) *Remember {
+	return &Remember{remember: remember}
+}
+
+func (t *Remember) Name() string {
+	return "remember"
+}
+
+func (t *Remember) Description() string {
+	return "Save a short fact worth knowing in future conversations, such as the user's preferences, projects or personal details they shared. Only call this for lasting information, not for things that matter only right now."
+}
+
+func (t *Remember) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{
+				"type":        "string",
+				"description": "The fact to remember, one self-contained sentence (e.g. 'The user prefers Go over Python')",
+			},
+		},
+		"required": []string{"content"},
+	}
+}
+
+func (t *Remember) Announce(args map[string]interface{}) string {
+	return "remembering"
+}
+
+func (t *Remember) Execute(ctx context.Context, args map[string]interface{}) (Result, error) {
+	content, _ := args["content"].(string)
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return Result{}, fmt.Errorf("content is required")
+	}
+	if err := t.remember(ctx, content); err != nil {
+		return Result{}, err
+	}
+	return Result{Content: "remembered: " + content}, nil
+}
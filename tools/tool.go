@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/curator4/io-tui/api"
+)
+
+// Tool is a function the model can call. Parameters is the JSON schema of
+// the arguments object, providers translate it into their own format.
+type Tool interface {
+	Name() string
+	Description() string
+	Parameters() map[string]interface{}
+	Execute(ctx context.Context, args map[string]interface{}) (Result, error)
+}
+
+// Result is what a tool hands back after running
+type Result struct {
+	// Content is sent back to the model as the function result
+	Content string
+	// SwitchAI asks the chat to switch to the named AI once the reply is done
+	SwitchAI string
+}
+
+// Announcer is implemented by tools that describe what they are doing
+// in the status panel while they run
+type Announcer interface {
+	Announce(args map[string]interface{}) string
+}
+
+// Registry holds the tools available to the chat, in registration order
+type Registry struct {
+	mu    sync.RWMutex
+	tools []Tool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a tool, replacing any tool with the same name
+func (r *Registry) Register(tool Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.tools {
+		if existing.Name() == tool.Name() {
+			r.tools[i] = tool
+			return
+		}
+	}
+	r.tools = append(r.tools, tool)
+}
+
+// Get looks up a tool by name
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tool := range r.tools {
+		if tool.Name() == name {
+			return tool, true
+		}
+	}
+	return nil, false
+}
+
+// All lists every registered tool
+func (r *Registry) All() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.tools)
+}
+
+// Enabled filters the tools by an AI's lists: a non-empty enabled list
+// allows only the tools named in it, disabled tools are always left out
+func (r *Registry) Enabled(enabled, disabled []string) []Tool {
+	var result []Tool
+	for _, tool := range r.All() {
+		if IsEnabled(tool.Name(), enabled, disabled) {
+			result = append(result, tool)
+		}
+	}
+	return result
+}
+
+// IsEnabled applies the enable/disable lists to a single tool name
+func IsEnabled(name string, enabled, disabled []string) bool {
+	if slices.Contains(disabled, name) {
+		return false
+	}
+	return len(enabled) == 0 || slices.Contains(enabled, name)
+}
+
+// Declarations describes tools in the provider independent format
+func Declarations(tools []Tool) []api.FunctionDeclaration {
+	var declarations []api.FunctionDeclaration
+	for _, tool := range tools {
+		declarations = append(declarations, api.FunctionDeclaration{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  tool.Parameters(),
+		})
+	}
+	return declarations
+}
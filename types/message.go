@@ -2,6 +2,38 @@ package types
 
 // Unified message type used throughout the application
 type Message struct {
+	// ID is the database row, 0 until the message is loaded from the database
+	ID      int
 	Role    string
 	Content string
-}
\ No newline at end of file
+
+	// FunctionCalls are the calls an assistant message asked for
+	FunctionCalls []FunctionCall
+	// FunctionCallID and FunctionName tie a "tool" message to the call it answers
+	FunctionCallID string
+	FunctionName   string
+
+	// Usage is the token count of the request that produced an assistant message
+	Usage Usage
+}
+
+// Usage counts the tokens of one request as reported by the provider
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Add sums two usages
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
+}
+
+// FunctionCall represents a function call from the AI
+type FunctionCall struct {
+	ID   string
+	Name string
+	Args map[string]interface{}
+}
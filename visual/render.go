@@ -0,0 +1,195 @@
+package visual
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nfnt/resize"
+	"github.com/qeesung/image2ascii/convert"
+)
+
+// Default size of the ascii art stored with an AI
+const (
+	DefaultArtWidth  = 30
+	DefaultArtHeight = 20
+)
+
+// thumbnailSize bounds the stored image, plenty for terminal sized art
+const thumbnailSize = 256
+
+// CellAspect is how much taller a terminal cell is than wide
+const CellAspect = 2.0
+
+func openImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// DecodeImage reads a stored thumbnail
+func DecodeImage(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// Thumbnail shrinks an image to at most thumbnailSize pixels a side and
+// encodes it as PNG for storage
+func Thumbnail(img image.Image) ([]byte, error) {
+	small := resize.Thumbnail(thumbnailSize, thumbnailSize, img, resize.Lanczos3)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, small); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ArtAspect is the columns per row that keep the image's proportions
+func ArtAspect(img image.Image) float64 {
+	bounds := img.Bounds()
+	if bounds.Dy() == 0 {
+		return 0
+	}
+	return float64(bounds.Dx()) / float64(bounds.Dy()) * CellAspect
+}
+
+// RenderASCII converts an image to colored ASCII art of exactly the given
+// size (preserving ANSI escape sequences)
+func RenderASCII(img image.Image, width, height int) string {
+	options := convert.DefaultOptions
+	options.FixedWidth = width
+	options.FixedHeight = height
+	options.Colored = true // Enable ANSI color codes in ASCII
+
+	ascii := convert.NewImageConverter().Image2ASCIIString(img, &options)
+	ascii = strings.TrimSpace(ascii)
+	lines := strings.Split(ascii, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ImageFromASCII rebuilds an image from colored ASCII art, one cell per
+// character, for AIs saved before their source image was kept. The result
+// is coarse but renders at other sizes. ok is false for art without colors.
+func ImageFromASCII(ascii string) (img image.Image, ok bool) {
+	var rows [][]color.RGBA
+	colored := false
+	for _, line := range strings.Split(ascii, "\n") {
+		var row []color.RGBA
+		current := color.RGBA{A: 255}
+		for i := 0; i < len(line); {
+			// Escape sequences set the color of the characters after them
+			if line[i] == 0x1b && i+1 < len(line) && line[i+1] == '[' {
+				end := strings.IndexByte(line[i:], 'm')
+				if end < 0 {
+					break
+				}
+				if c, set := sgrColor(line[i+2 : i+end]); set {
+					current, colored = c, true
+				}
+				i += end + 1
+				continue
+			}
+			_, size := utf8.DecodeRuneInString(line[i:])
+			row = append(row, current)
+			i += size
+		}
+		rows = append(rows, row)
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	if !colored || width < 2 || len(rows) < 2 {
+		return nil, false
+	}
+
+	// Two pixels per cell vertically keeps the art's proportions
+	rgba := image.NewRGBA(image.Rect(0, 0, width, len(rows)*2))
+	for y, row := range rows {
+		for x, c := range row {
+			rgba.SetRGBA(x, y*2, c)
+			rgba.SetRGBA(x, y*2+1, c)
+		}
+	}
+	return rgba, true
+}
+
+// sgrColor reads a foreground color from SGR parameters, 24-bit
+// ("38;2;r;g;b") or 256-color ("38;5;n"). set is false for anything else
+// such as a reset.
+func sgrColor(params string) (c color.RGBA, set bool) {
+	parts := strings.Split(params, ";")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] != "38" {
+			continue
+		}
+		switch {
+		case parts[i+1] == "5":
+			n, err := strconv.Atoi(parts[i+2])
+			if err != nil || n < 0 || n > 255 {
+				return color.RGBA{}, false
+			}
+			return xtermColor(n), true
+		case parts[i+1] == "2" && i+4 < len(parts):
+			var rgb [3]uint8
+			for j := range rgb {
+				n, err := strconv.Atoi(parts[i+2+j])
+				if err != nil || n < 0 || n > 255 {
+					return color.RGBA{}, false
+				}
+				rgb[j] = uint8(n)
+			}
+			return color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 255}, true
+		}
+	}
+	return color.RGBA{}, false
+}
+
+// xtermColor is color n of the xterm 256-color palette
+func xtermColor(n int) color.RGBA {
+	basic := [16][3]uint8{
+		{0, 0, 0}, {128, 0, 0}, {0, 128, 0}, {128, 128, 0},
+		{0, 0, 128}, {128, 0, 128}, {0, 128, 128}, {192, 192, 192},
+		{128, 128, 128}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+		{0, 0, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+	}
+	switch {
+	case n < 16:
+		return color.RGBA{R: basic[n][0], G: basic[n][1], B: basic[n][2], A: 255}
+	case n < 232:
+		// 6x6x6 cube
+		level := func(v int) uint8 {
+			if v == 0 {
+				return 0
+			}
+			return uint8(55 + v*40)
+		}
+		n -= 16
+		return color.RGBA{R: level(n / 36), G: level(n / 6 % 6), B: level(n % 6), A: 255}
+	default:
+		gray := uint8(8 + (n-232)*10)
+		return color.RGBA{R: gray, G: gray, B: gray, A: 255}
+	}
+}
@@ -6,36 +6,45 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/cascax/colorthief-go"
-	"github.com/qeesung/image2ascii/convert"
 )
 
 // GenerateFromImageURL downloads an image from URL and generates both
 // a color palette and ASCII art from it
-func GenerateFromImageURL(imageURL string) (palette []string, ascii string, err error) {
+func GenerateFromImageURL(imageURL string) (palette []string, ascii string, thumbnail []byte, err error) {
 	// Download image to temporary file
 	tempPath, err := downloadImage(imageURL)
 	if err != nil {
-		return nil, "", fmt.Errorf("❌ Failed to download image from URL")
+		return nil, "", nil, fmt.Errorf("❌ Failed to download image from URL")
 	}
 	defer os.Remove(tempPath) // Clean up temp file
 	
+	return GenerateFromImageFile(tempPath)
+}
+
+// GenerateFromImageFile generates a color palette and ASCII art from a local
+// image, plus the thumbnail to store so the art can be rendered at other sizes
+func GenerateFromImageFile(imagePath string) (palette []string, ascii string, thumbnail []byte, err error) {
 	// Extract color palette
-	palette, err = extractPalette(tempPath)
+	palette, err = extractPalette(imagePath)
 	if err != nil {
-		return nil, "", fmt.Errorf("🎨 Failed to extract color palette from image")
+		return nil, "", nil, fmt.Errorf("🎨 Failed to extract color palette from image")
 	}
 	
-	// Generate ASCII art
-	ascii, err = generateASCII(tempPath)
+	img, err := openImage(imagePath)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("🖼️ Failed to generate ASCII art from image")
+	}
+	thumbnail, err = Thumbnail(img)
 	if err != nil {
-		return nil, "", fmt.Errorf("🖼️ Failed to generate ASCII art from image")
+		return nil, "", nil, fmt.Errorf("🖼️ Failed to store image: %w", err)
 	}
 	
-	return palette, ascii, nil
+	// ASCII art at the default size, the layout renders other sizes
+	ascii = RenderASCII(img, DefaultArtWidth, DefaultArtHeight)
+	return palette, ascii, thumbnail, nil
 }
 
 // extractPalette uses colorthief to extract dominant colors from an image
@@ -58,32 +67,6 @@ func extractPalette(imagePath string) ([]string, error) {
 	return palette, nil
 }
 
-// generateASCII converts an image to ASCII art
-func generateASCII(imagePath string) (string, error) {
-	// Create converter with options
-	converter := convert.NewImageConverter()
-	
-	// Set options for colored ASCII output
-	options := convert.DefaultOptions
-	options.FixedWidth = 30    // Fixed width for consistent display
-	options.FixedHeight = 20   // Fixed height for header area
-	options.Colored = true     // Enable ANSI color codes in ASCII
-	
-	// Convert image to ASCII
-	ascii := converter.ImageFile2ASCIIString(imagePath, &options)
-	
-	// Ensure exactly 20 lines (preserve ANSI escape sequences)
-	ascii = strings.TrimSpace(ascii)
-	lines := strings.Split(ascii, "\n")
-	if len(lines) > 20 {
-		// Only trim if we have more than 20 actual lines
-		lines = lines[:20]
-		ascii = strings.Join(lines, "\n")
-	}
-	
-	return ascii, nil
-}
-
 // downloadImage downloads an image from URL to a temporary file
 func downloadImage(url string) (string, error) {
 	// Create HTTP client with timeout and proper headers